@@ -0,0 +1,23 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// cancelTimeout is the maximum time to wait for a deployment to be canceled.
+const cancelTimeout = 30 * time.Second
+
+// cancelDeployment cancels the given deployment. The given context is likely already canceled
+// itself, so the cancellation uses a detached context.
+func (d *deployer) cancelDeployment(ctx context.Context, appID, deploymentID string) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cancelTimeout)
+	defer cancel()
+
+	d.action.Infof("canceling deployment %s", deploymentID)
+	if _, _, err := d.deployments.CancelDeployment(ctx, appID, deploymentID); err != nil {
+		d.action.Errorf("failed to cancel deployment %s: %v", deploymentID, err)
+		return
+	}
+	d.action.Infof("deployment %s was canceled", deploymentID)
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/digitalocean/godo"
+	gha "github.com/sethvargo/go-githubactions"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWaitForDeploymentTerminalCancelsDeployment(t *testing.T) {
+	appID := "app-id"
+	deploymentID := "deployment-id"
+
+	tests := []struct {
+		name         string
+		cancelErr    error
+		expectedLogs string
+	}{{
+		name: "success",
+		expectedLogs: `deployment is in phase: BUILDING
+canceling deployment deployment-id
+deployment deployment-id was canceled
+`,
+	}, {
+		name:      "cancellation fails",
+		cancelErr: errors.New("an error"),
+		expectedLogs: `deployment is in phase: BUILDING
+canceling deployment deployment-id
+::error::failed to cancel deployment deployment-id: an error
+`,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			as := &mockedAppsService{}
+			// Cancel the context while the deployment is still building.
+			as.On("GetDeployment", ctx, appID, deploymentID).Return(&godo.Deployment{
+				Phase: godo.DeploymentPhase_Building,
+			}, &godo.Response{}, nil).Run(func(mock.Arguments) { cancel() })
+			ds := &mockedDeploymentService{}
+			ds.On("CancelDeployment", mock.Anything, appID, deploymentID).Return(&godo.Deployment{}, &godo.Response{}, test.cancelErr).Run(func(args mock.Arguments) {
+				// The cancellation must not use the already canceled context.
+				require.NoError(t, args.Get(0).(context.Context).Err())
+			})
+
+			var actionLogs bytes.Buffer
+			d := &deployer{
+				action:      gha.New(gha.WithWriter(&actionLogs)),
+				apps:        as,
+				deployments: ds,
+			}
+
+			_, err := d.waitForDeploymentTerminal(ctx, appID, deploymentID, nil)
+			require.ErrorIs(t, err, context.Canceled)
+			require.Equal(t, test.expectedLogs, actionLogs.String())
+
+			as.AssertExpectations(t)
+			ds.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDeployCancelsCausedDeploymentOnCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	appID := "app-id"
+	spec := &godo.AppSpec{Name: "foo"}
+
+	as := &mockedAppsService{}
+	as.On("List", ctx, mock.Anything).Return([]*godo.App{{ID: appID, Spec: spec}}, &godo.Response{}, nil)
+	as.On("Propose", ctx, mock.Anything).Return(&godo.AppProposeResponse{}, &godo.Response{}, nil)
+	as.On("ListDeployments", ctx, appID, mock.Anything).Return([]*godo.Deployment{}, &godo.Response{}, nil)
+	as.On("Update", ctx, appID, mock.Anything).Return(&godo.App{
+		ID:                appID,
+		PendingDeployment: &godo.Deployment{ID: "update-id"},
+	}, &godo.Response{}, nil)
+	// Cancel the context while the rebuild is being created.
+	as.On("CreateDeployment", ctx, appID, mock.Anything).Return((*godo.Deployment)(nil), &godo.Response{}, context.Canceled).Run(func(mock.Arguments) { cancel() })
+	ds := &mockedDeploymentService{}
+	ds.On("CancelDeployment", mock.Anything, appID, "update-id").Return(&godo.Deployment{}, &godo.Response{}, nil)
+
+	var actionLogs bytes.Buffer
+	outputFilePath := t.TempDir() + "/output"
+	d := &deployer{
+		action: gha.New(gha.WithWriter(&actionLogs), gha.WithGetenv(func(k string) string {
+			if k == "GITHUB_OUTPUT" {
+				return outputFilePath
+			}
+			return ""
+		})),
+		apps:        as,
+		deployments: ds,
+		inputs:      inputs{forceRebuild: true},
+	}
+
+	_, err := d.deploy(ctx, spec)
+	require.ErrorIs(t, err, context.Canceled)
+	require.Contains(t, actionLogs.String(), `canceling deployment update-id
+deployment update-id was canceled
+`)
+	as.AssertExpectations(t)
+	ds.AssertExpectations(t)
+}
+
+type mockedDeploymentService struct {
+	mock.Mock
+}
+
+func (m *mockedDeploymentService) CancelDeployment(ctx context.Context, appID, deploymentID string) (*godo.Deployment, *godo.Response, error) {
+	args := m.Called(ctx, appID, deploymentID)
+	return args.Get(0).(*godo.Deployment), args.Get(1).(*godo.Response), args.Error(2)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/digitalocean/godo"
+)
+
+// findDeployment returns the deployment caused by creating or updating the given app, as returned
+// by the respective call. Only if that doesn't reference one, the new deployments are looked up.
+// Deployments that already existed before are ignored. If no new deployment exists, for example
+// because the spec didn't change, a deployment is created explicitly.
+func (d *deployer) findDeployment(ctx context.Context, app *godo.App, previous []*godo.Deployment) (*godo.Deployment, error) {
+	if dep := causedDeployment(app, previous); dep != nil {
+		return dep, nil
+	}
+
+	ds, _, err := d.apps.ListDeployments(ctx, app.GetID(), &godo.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deployments: %w", err)
+	}
+
+	known := deploymentIDs(previous)
+	var candidates []*godo.Deployment
+	for _, dep := range ds {
+		if !known[dep.GetID()] {
+			candidates = append(candidates, dep)
+		}
+	}
+
+	switch len(candidates) {
+	case 0:
+		d.action.Infof("no deployment was created, creating one...")
+		dep, _, err := d.apps.CreateDeployment(ctx, app.GetID())
+		if err != nil {
+			return nil, fmt.Errorf("failed to create deployment: %w", err)
+		}
+		return dep, nil
+	case 1:
+		return candidates[0], nil
+	}
+
+	// Other deployments were created concurrently, so pick the one deploying the spec we've sent.
+	// The app's spec is the one returned by the update, so it carries the same defaults the API
+	// fills in for the deployments' specs.
+	for _, dep := range candidates {
+		if reflect.DeepEqual(dep.GetSpec(), app.GetSpec()) {
+			return dep, nil
+		}
+	}
+	return nil, fmt.Errorf("failed to identify the deployment caused by the update among %d new deployments", len(candidates))
+}
+
+// causedDeployment returns the pending or in-progress deployment referenced by the given app as
+// returned by creating or updating it, unless it's one of the given previous deployments.
+func causedDeployment(app *godo.App, previous []*godo.Deployment) *godo.Deployment {
+	known := deploymentIDs(previous)
+	for _, dep := range []*godo.Deployment{app.GetPendingDeployment(), app.GetInProgressDeployment()} {
+		if dep != nil && !known[dep.GetID()] {
+			return dep
+		}
+	}
+	return nil
+}
+
+// deploymentIDs returns the set of IDs of the given deployments.
+func deploymentIDs(ds []*godo.Deployment) map[string]bool {
+	ids := make(map[string]bool, len(ds))
+	for _, dep := range ds {
+		ids[dep.GetID()] = true
+	}
+	return ids
+}
+
+// forceRebuild explicitly creates a deployment that rebuilds all components without using the
+// build cache. It supersedes the deployment implicitly caused by updating the app, if any.
+func (d *deployer) forceRebuild(ctx context.Context, appID string) (*godo.Deployment, error) {
+	d.action.Infof("force_rebuild is set, creating a deployment that rebuilds all components...")
+	dep, _, err := d.apps.CreateDeployment(ctx, appID, &godo.DeploymentCreateRequest{ForceBuild: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create deployment: %w", err)
+	}
+	return dep, nil
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/digitalocean/godo"
+	gha "github.com/sethvargo/go-githubactions"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindDeployment(t *testing.T) {
+	ctx := context.Background()
+	appID := "app-id"
+	spec := &godo.AppSpec{Name: "foo", Services: []*godo.AppServiceSpec{{Name: "web"}}}
+	otherSpec := &godo.AppSpec{Name: "foo", Services: []*godo.AppServiceSpec{{Name: "api"}}}
+	previous := []*godo.Deployment{{ID: "old-id"}}
+
+	tests := []struct {
+		name         string
+		app          *godo.App
+		deployments  []*godo.Deployment
+		created      *godo.Deployment
+		expectedID   string
+		expectedLogs string
+		err          bool
+	}{{
+		name:       "pending deployment",
+		app:        &godo.App{ID: appID, Spec: spec, PendingDeployment: &godo.Deployment{ID: "new-id"}},
+		expectedID: "new-id",
+	}, {
+		name:       "in-progress deployment",
+		app:        &godo.App{ID: appID, Spec: spec, InProgressDeployment: &godo.Deployment{ID: "new-id"}},
+		expectedID: "new-id",
+	}, {
+		name:        "single new deployment",
+		app:         &godo.App{ID: appID, Spec: spec},
+		deployments: []*godo.Deployment{{ID: "new-id"}, {ID: "old-id"}},
+		expectedID:  "new-id",
+	}, {
+		name:        "ignores previous in-progress deployment",
+		app:         &godo.App{ID: appID, Spec: spec, InProgressDeployment: &godo.Deployment{ID: "old-id"}},
+		deployments: []*godo.Deployment{{ID: "new-id"}, {ID: "old-id"}},
+		expectedID:  "new-id",
+	}, {
+		name: "concurrent deployments",
+		app:  &godo.App{ID: appID, Spec: spec},
+		deployments: []*godo.Deployment{
+			{ID: "concurrent-id", Spec: otherSpec},
+			{ID: "new-id", Spec: spec},
+			{ID: "old-id"},
+		},
+		expectedID: "new-id",
+	}, {
+		name: "concurrent deployments with specs as returned by the API",
+		// The API fills in defaults, so the specs are only equal to the one of the updated app,
+		// not to the one that was sent.
+		app: &godo.App{ID: appID, Spec: defaultedSpec("web")},
+		deployments: []*godo.Deployment{
+			{ID: "concurrent-id", Spec: defaultedSpec("api")},
+			{ID: "new-id", Spec: defaultedSpec("web")},
+			{ID: "old-id"},
+		},
+		expectedID: "new-id",
+	}, {
+		name: "ambiguous deployments",
+		app:  &godo.App{ID: appID, Spec: spec},
+		deployments: []*godo.Deployment{
+			{ID: "concurrent-id", Spec: otherSpec},
+			{ID: "other-concurrent-id", Spec: otherSpec},
+			{ID: "old-id"},
+		},
+		err: true,
+	}, {
+		name:         "no new deployment",
+		app:          &godo.App{ID: appID, Spec: spec},
+		deployments:  []*godo.Deployment{{ID: "old-id"}},
+		created:      &godo.Deployment{ID: "created-id"},
+		expectedID:   "created-id",
+		expectedLogs: "no deployment was created, creating one...\n",
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			as := &mockedAppsService{}
+			if test.deployments != nil {
+				as.On("ListDeployments", ctx, appID, mock.Anything).Return(test.deployments, &godo.Response{}, nil)
+			}
+			if test.created != nil {
+				as.On("CreateDeployment", ctx, appID, mock.Anything).Return(test.created, &godo.Response{}, nil)
+			}
+
+			var actionLogs bytes.Buffer
+			d := &deployer{
+				action: gha.New(gha.WithWriter(&actionLogs)),
+				apps:   as,
+			}
+
+			dep, err := d.findDeployment(ctx, test.app, previous)
+			if test.err {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.expectedID, dep.GetID())
+			}
+			require.Equal(t, test.expectedLogs, actionLogs.String())
+			as.AssertExpectations(t)
+		})
+	}
+}
+
+// defaultedSpec returns a spec with a single service of the given name, with the defaults filled in
+// like the API does. A new value is returned every time.
+func defaultedSpec(service string) *godo.AppSpec {
+	return &godo.AppSpec{
+		Name:   "foo",
+		Region: "ams",
+		Services: []*godo.AppServiceSpec{{
+			Name:             service,
+			Image:            &godo.ImageSourceSpec{RegistryType: godo.ImageSourceSpecRegistryType_DockerHub, Registry: "library", Repository: "nginx", Tag: "latest"},
+			InstanceCount:    1,
+			InstanceSizeSlug: "apps-s-1vcpu-0.5gb",
+			HTTPPort:         8080,
+		}},
+		Ingress: &godo.AppIngressSpec{Rules: []*godo.AppIngressSpecRule{{
+			Match:     &godo.AppIngressSpecRuleMatch{Path: &godo.AppIngressSpecRuleStringMatch{Prefix: "/"}},
+			Component: &godo.AppIngressSpecRuleRoutingComponent{Name: service},
+		}}},
+	}
+}
+
+func TestForceRebuild(t *testing.T) {
+	ctx := context.Background()
+	appID := "app-id"
+
+	as := &mockedAppsService{}
+	as.On("CreateDeployment", ctx, appID, []*godo.DeploymentCreateRequest{{ForceBuild: true}}).Return(&godo.Deployment{ID: "rebuild-id"}, &godo.Response{}, nil)
+
+	var actionLogs bytes.Buffer
+	d := &deployer{
+		action: gha.New(gha.WithWriter(&actionLogs)),
+		apps:   as,
+	}
+
+	dep, err := d.forceRebuild(ctx, appID)
+	require.NoError(t, err)
+	require.Equal(t, "rebuild-id", dep.GetID())
+	require.Equal(t, "force_rebuild is set, creating a deployment that rebuilds all components...\n", actionLogs.String())
+	as.AssertExpectations(t)
+}
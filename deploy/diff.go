@@ -0,0 +1,280 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/digitalocean/godo"
+)
+
+const redacted = "<redacted>"
+
+// reportSpecDiff computes the diff between the current and the desired spec, prints it and
+// surfaces it as the spec_diff output.
+func (d *deployer) reportSpecDiff(current, desired *godo.AppSpec) (specDiff, error) {
+	diff, err := diffSpecs(current, desired)
+	if err != nil {
+		return diff, err
+	}
+
+	d.action.Group("spec diff")
+	d.action.Infof(diff.String())
+	d.action.EndGroup()
+
+	diffJSON, err := json.Marshal(diff)
+	if err != nil {
+		return diff, fmt.Errorf("failed to marshal spec diff: %w", err)
+	}
+	d.action.SetOutput("spec_diff", string(diffJSON))
+	return diff, nil
+}
+
+// specDiff is a structured diff between two app specs.
+type specDiff struct {
+	// Fields are the app-level changes, like domains and app-wide env vars.
+	Fields []fieldChange `json:"fields,omitempty"`
+	// Components are the per-component changes.
+	Components []componentDiff `json:"components,omitempty"`
+}
+
+// componentDiff describes the changes to a single component.
+type componentDiff struct {
+	Name   string                `json:"name"`
+	Type   godo.AppComponentType `json:"type"`
+	Action string                `json:"action"`
+	Fields []fieldChange         `json:"fields,omitempty"`
+}
+
+// fieldChange describes the change of a single field.
+type fieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old,omitempty"`
+	New   string `json:"new,omitempty"`
+}
+
+const (
+	actionAdded   = "added"
+	actionRemoved = "removed"
+	actionChanged = "changed"
+)
+
+// isEmpty returns whether or not the diff contains any changes.
+func (d specDiff) isEmpty() bool {
+	return len(d.Fields) == 0 && len(d.Components) == 0
+}
+
+// String renders the diff in a human-readable form.
+func (d specDiff) String() string {
+	if d.isEmpty() {
+		return "no changes"
+	}
+
+	var sb strings.Builder
+	if len(d.Fields) > 0 {
+		sb.WriteString("app changed\n")
+		writeFieldChanges(&sb, d.Fields)
+	}
+	for _, c := range d.Components {
+		fmt.Fprintf(&sb, "%s %q %s\n", c.Type, c.Name, c.Action)
+		writeFieldChanges(&sb, c.Fields)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// writeFieldChanges writes the given field changes into the builder, one per line.
+func writeFieldChanges(sb *strings.Builder, changes []fieldChange) {
+	for _, c := range changes {
+		fmt.Fprintf(sb, "  %s: %q -> %q\n", c.Field, c.Old, c.New)
+	}
+}
+
+// diffSpecs computes the diff between the current and the desired spec. Values of secret
+// env vars are redacted and secrets that stay secrets are left out, see diffFields.
+func diffSpecs(current, desired *godo.AppSpec) (specDiff, error) {
+	var diff specDiff
+	diff.Fields = diffFields(appFields(current), appFields(desired))
+
+	currentComponents, err := componentsByName(current)
+	if err != nil {
+		return diff, err
+	}
+	desiredComponents, err := componentsByName(desired)
+	if err != nil {
+		return diff, err
+	}
+
+	names := make([]string, 0, len(currentComponents)+len(desiredComponents))
+	for name := range currentComponents {
+		names = append(names, name)
+	}
+	for name := range desiredComponents {
+		if _, ok := currentComponents[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		cur, hasCur := currentComponents[name]
+		des, hasDes := desiredComponents[name]
+
+		cd := componentDiff{Name: name}
+		switch {
+		case !hasCur:
+			cd.Type = des.GetType()
+			cd.Action = actionAdded
+			cd.Fields = diffFields(nil, componentFields(desired, des))
+		case !hasDes:
+			cd.Type = cur.GetType()
+			cd.Action = actionRemoved
+			cd.Fields = diffFields(componentFields(current, cur), nil)
+		default:
+			cd.Type = des.GetType()
+			cd.Action = actionChanged
+			cd.Fields = diffFields(componentFields(current, cur), componentFields(desired, des))
+			if len(cd.Fields) == 0 {
+				continue
+			}
+		}
+		diff.Components = append(diff.Components, cd)
+	}
+	return diff, nil
+}
+
+// componentsByName returns all components of the given spec, keyed by their name.
+func componentsByName(spec *godo.AppSpec) (map[string]godo.AppComponentSpec, error) {
+	components := make(map[string]godo.AppComponentSpec)
+	if spec == nil {
+		return components, nil
+	}
+	if err := spec.ForEachAppComponentSpec(func(c godo.AppComponentSpec) error {
+		components[c.GetName()] = c
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("failed to iterate components: %w", err)
+	}
+	return components, nil
+}
+
+// field is a flattened field of a spec.
+type field struct {
+	value  string
+	secret bool
+}
+
+// appFields flattens the app-level fields that are relevant for the diff.
+func appFields(spec *godo.AppSpec) map[string]field {
+	fields := make(map[string]field)
+	if spec == nil {
+		return fields
+	}
+	for _, domain := range spec.Domains {
+		typ := domain.Type
+		if typ == "" {
+			typ = godo.AppDomainSpecType_Default
+		}
+		fields["domains."+domain.Domain] = field{value: string(typ)}
+	}
+	addEnvFields(fields, spec.Envs)
+	return fields
+}
+
+// componentFields flattens the fields of the given component that are relevant for the diff.
+func componentFields(spec *godo.AppSpec, c godo.AppComponentSpec) map[string]field {
+	fields := make(map[string]field)
+	if cc, ok := c.(godo.AppContainerComponentSpec); ok {
+		if size := cc.GetInstanceSizeSlug(); size != "" {
+			fields["instance_size_slug"] = field{value: size}
+		}
+		if count := cc.GetInstanceCount(); count != 0 {
+			fields["instance_count"] = field{value: strconv.FormatInt(count, 10)}
+		}
+		if image := cc.GetImage(); image != nil {
+			fields["image"] = field{value: formatImage(image)}
+		}
+	}
+	if bc, ok := c.(godo.AppBuildableComponentSpec); ok {
+		addEnvFields(fields, bc.GetEnvs())
+	}
+	if routes := componentRoutes(spec, c); len(routes) > 0 {
+		fields["routes"] = field{value: strings.Join(routes, ",")}
+	}
+	return fields
+}
+
+// componentRoutes returns the sorted route paths of the given component, both from the
+// (deprecated) component-level routes and the app-level ingress rules.
+func componentRoutes(spec *godo.AppSpec, c godo.AppComponentSpec) []string {
+	var routes []string
+	if rc, ok := c.(godo.AppRoutableComponentSpec); ok {
+		for _, r := range rc.GetRoutes() {
+			routes = append(routes, r.Path)
+		}
+	}
+	if spec.Ingress != nil {
+		for _, rule := range spec.Ingress.Rules {
+			if rule.Component == nil || rule.Component.Name != c.GetName() {
+				continue
+			}
+			if rule.Match != nil && rule.Match.Path != nil {
+				routes = append(routes, rule.Match.Path.Prefix)
+			}
+		}
+	}
+	slices.Sort(routes)
+	return routes
+}
+
+// addEnvFields adds the given env vars to the fields.
+func addEnvFields(fields map[string]field, envs []*godo.AppVariableDefinition) {
+	for _, env := range envs {
+		fields["envs."+env.Key] = field{value: env.Value, secret: env.Type == godo.AppVariableType_Secret}
+	}
+}
+
+// diffFields computes the changes between the given flattened fields, sorted by field name.
+// Values of secret fields are redacted. Fields that are secret on both sides are left out, as
+// the existing app only returns their ciphertext, which can't be compared to the desired value.
+func diffFields(current, desired map[string]field) []fieldChange {
+	var changes []fieldChange
+	for name, cur := range current {
+		des, ok := desired[name]
+		if ok && (des == cur || des.secret && cur.secret) {
+			continue
+		}
+		changes = append(changes, fieldChange{Field: name, Old: cur.String(), New: des.String()})
+	}
+	for name, des := range desired {
+		if _, ok := current[name]; ok {
+			continue
+		}
+		changes = append(changes, fieldChange{Field: name, New: des.String()})
+	}
+	slices.SortFunc(changes, func(a, b fieldChange) int {
+		return strings.Compare(a.Field, b.Field)
+	})
+	return changes
+}
+
+// String returns the value of the field, redacted if it's a secret.
+func (f field) String() string {
+	if f.secret && f.value != "" {
+		return redacted
+	}
+	return f.value
+}
+
+// formatImage formats the given image reference into a human-readable string.
+func formatImage(image *godo.ImageSourceSpec) string {
+	ref := strings.Trim(strings.Join([]string{string(image.RegistryType), image.Registry, image.Repository}, "/"), "/")
+	if image.Digest != "" {
+		return ref + "@" + image.Digest
+	}
+	if image.Tag != "" {
+		return ref + ":" + image.Tag
+	}
+	return ref
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/digitalocean/godo"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffSpecs(t *testing.T) {
+	current := &godo.AppSpec{
+		Name:    "foo",
+		Domains: []*godo.AppDomainSpec{{Domain: "foo.com", Type: godo.AppDomainSpecType_Primary}},
+		Envs: []*godo.AppVariableDefinition{{
+			Key:   "GLOBAL",
+			Value: "a",
+		}},
+		Services: []*godo.AppServiceSpec{{
+			Name:             "web",
+			InstanceSizeSlug: "basic-xxs",
+			InstanceCount:    1,
+			Image: &godo.ImageSourceSpec{
+				RegistryType: godo.ImageSourceSpecRegistryType_Ghcr,
+				Registry:     "foo",
+				Repository:   "bar",
+				Tag:          "v1",
+			},
+			Envs: []*godo.AppVariableDefinition{{
+				Key:   "PLAIN",
+				Value: "old",
+			}, {
+				Key:   "SECRET",
+				Value: "EV[1:abc:def]",
+				Type:  godo.AppVariableType_Secret,
+			}, {
+				Key:   "NO_LONGER_SECRET",
+				Value: "EV[1:ghi:jkl]",
+				Type:  godo.AppVariableType_Secret,
+			}},
+		}, {
+			Name:             "unchanged",
+			InstanceSizeSlug: "basic-xxs",
+		}},
+		Workers: []*godo.AppWorkerSpec{{
+			Name: "worker",
+		}},
+		Ingress: &godo.AppIngressSpec{
+			Rules: []*godo.AppIngressSpecRule{{
+				Match:     &godo.AppIngressSpecRuleMatch{Path: &godo.AppIngressSpecRuleStringMatch{Prefix: "/"}},
+				Component: &godo.AppIngressSpecRuleRoutingComponent{Name: "web"},
+			}},
+		},
+	}
+
+	desired := &godo.AppSpec{
+		Name:    "foo",
+		Domains: []*godo.AppDomainSpec{{Domain: "bar.com"}},
+		Envs: []*godo.AppVariableDefinition{{
+			Key:   "GLOBAL",
+			Value: "a",
+		}},
+		Services: []*godo.AppServiceSpec{{
+			Name:             "web",
+			InstanceSizeSlug: "basic-xs",
+			InstanceCount:    1,
+			Image: &godo.ImageSourceSpec{
+				RegistryType: godo.ImageSourceSpecRegistryType_Ghcr,
+				Registry:     "foo",
+				Repository:   "bar",
+				Digest:       "sha256:1234",
+			},
+			Envs: []*godo.AppVariableDefinition{{
+				Key:   "PLAIN",
+				Value: "new",
+			}, {
+				Key:   "SECRET",
+				Value: "new-secret",
+				Type:  godo.AppVariableType_Secret,
+			}, {
+				Key:   "NO_LONGER_SECRET",
+				Value: "plain",
+			}},
+		}, {
+			Name:             "unchanged",
+			InstanceSizeSlug: "basic-xxs",
+		}},
+		Jobs: []*godo.AppJobSpec{{
+			Name: "job",
+			Envs: []*godo.AppVariableDefinition{{
+				Key:   "JOB_SECRET",
+				Value: "secret",
+				Type:  godo.AppVariableType_Secret,
+			}},
+		}},
+		Ingress: &godo.AppIngressSpec{
+			Rules: []*godo.AppIngressSpecRule{{
+				Match:     &godo.AppIngressSpecRuleMatch{Path: &godo.AppIngressSpecRuleStringMatch{Prefix: "/api"}},
+				Component: &godo.AppIngressSpecRuleRoutingComponent{Name: "web"},
+			}},
+		},
+	}
+
+	diff, err := diffSpecs(current, desired)
+	require.NoError(t, err)
+
+	expected := specDiff{
+		Fields: []fieldChange{
+			{Field: "domains.bar.com", New: "DEFAULT"},
+			{Field: "domains.foo.com", Old: "PRIMARY"},
+		},
+		Components: []componentDiff{{
+			Name:   "job",
+			Type:   godo.AppComponentTypeJob,
+			Action: actionAdded,
+			Fields: []fieldChange{{Field: "envs.JOB_SECRET", New: redacted}},
+		}, {
+			Name:   "web",
+			Type:   godo.AppComponentTypeService,
+			Action: actionChanged,
+			Fields: []fieldChange{
+				{Field: "envs.NO_LONGER_SECRET", Old: redacted, New: "plain"},
+				{Field: "envs.PLAIN", Old: "old", New: "new"},
+				{Field: "image", Old: "GHCR/foo/bar:v1", New: "GHCR/foo/bar@sha256:1234"},
+				{Field: "instance_size_slug", Old: "basic-xxs", New: "basic-xs"},
+				{Field: "routes", Old: "/", New: "/api"},
+			},
+		}, {
+			Name:   "worker",
+			Type:   godo.AppComponentTypeWorker,
+			Action: actionRemoved,
+		}},
+	}
+	require.Equal(t, expected, diff)
+
+	require.Equal(t, `app changed
+  domains.bar.com: "" -> "DEFAULT"
+  domains.foo.com: "PRIMARY" -> ""
+job "job" added
+  envs.JOB_SECRET: "" -> "<redacted>"
+service "web" changed
+  envs.NO_LONGER_SECRET: "<redacted>" -> "plain"
+  envs.PLAIN: "old" -> "new"
+  image: "GHCR/foo/bar:v1" -> "GHCR/foo/bar@sha256:1234"
+  instance_size_slug: "basic-xxs" -> "basic-xs"
+  routes: "/" -> "/api"
+worker "worker" removed`, diff.String())
+}
+
+func TestDiffSpecsNoChanges(t *testing.T) {
+	spec := &godo.AppSpec{
+		Name:     "foo",
+		Services: []*godo.AppServiceSpec{{Name: "web"}},
+	}
+
+	diff, err := diffSpecs(spec, spec)
+	require.NoError(t, err)
+	require.True(t, diff.isEmpty())
+	require.Equal(t, "no changes", diff.String())
+
+	// Existing apps return the ciphertext of secrets, which can't be compared to the plaintext.
+	current := &godo.AppSpec{Name: "foo", Envs: []*godo.AppVariableDefinition{{Key: "SECRET", Value: "EV[1:abc:def]", Type: godo.AppVariableType_Secret}}}
+	desired := &godo.AppSpec{Name: "foo", Envs: []*godo.AppVariableDefinition{{Key: "SECRET", Value: "plaintext", Type: godo.AppVariableType_Secret}}}
+	diff, err = diffSpecs(current, desired)
+	require.NoError(t, err)
+	require.True(t, diff.isEmpty())
+}
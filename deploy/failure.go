@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/digitalocean/godo"
+)
+
+// maxFailureAnnotations caps the annotations emitted per component, as GitHub only shows a
+// limited amount of annotations per step anyway.
+const maxFailureAnnotations = 10
+
+var (
+	// logPrefixRegexp matches the component name and timestamp App Platform prefixes log lines with.
+	logPrefixRegexp = regexp.MustCompile(`^(?:\S+\s+)?\d{4}-\d{2}-\d{2}T[\d:.]+Z\s+`)
+	// fileErrorRegexps match compiler errors pointing at a file and line, like Go's
+	// "./main.go:12:3: undefined: foo", GCC's "main.c:3:5: error: ..." or TypeScript's
+	// "src/index.ts(12,5): error TS2304: ...".
+	fileErrorRegexps = []*regexp.Regexp{
+		regexp.MustCompile(`^(?P<file>[\w./-]+\.\w+):(?P<line>\d+)(?::(?P<col>\d+))?:\s*(?P<message>.+)$`),
+		regexp.MustCompile(`^(?P<file>[\w./-]+\.\w+)\((?P<line>\d+),(?P<col>\d+)\):\s*(?P<message>error.+)$`),
+	}
+	// genericErrorRegexps match errors that don't point at a line, like npm errors and missing files.
+	genericErrorRegexps = []*regexp.Regexp{
+		regexp.MustCompile(`^npm (?:ERR!|error) .+`),
+		regexp.MustCompile(`(?i)no such file or directory|cannot find module|file not found|not found in build context`),
+	}
+)
+
+// buildFailure is a failure signature found in build logs.
+type buildFailure struct {
+	file    string
+	line    string
+	col     string
+	message string
+}
+
+// parseBuildFailures returns the failure signatures found in the given build logs. File paths are
+// made relative to the repository using the component's source directory.
+func parseBuildFailures(logs, sourceDir string) []buildFailure {
+	var failures []buildFailure
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(logs, "\n") {
+		line = strings.TrimSpace(logPrefixRegexp.ReplaceAllString(line, ""))
+		if line == "" || seen[line] {
+			continue
+		}
+
+		if f, ok := parseFileError(line); ok {
+			f.file = repoPath(f.file, sourceDir)
+			failures = append(failures, f)
+		} else if isGenericError(line) {
+			failures = append(failures, buildFailure{message: line})
+		} else {
+			continue
+		}
+		seen[line] = true
+
+		if len(failures) == maxFailureAnnotations {
+			break
+		}
+	}
+	return failures
+}
+
+// parseFileError parses the given line as an error pointing at a file and line.
+func parseFileError(line string) (buildFailure, bool) {
+	for _, re := range fileErrorRegexps {
+		m := re.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		return buildFailure{
+			file:    m[re.SubexpIndex("file")],
+			line:    m[re.SubexpIndex("line")],
+			col:     m[re.SubexpIndex("col")],
+			message: m[re.SubexpIndex("message")],
+		}, true
+	}
+	return buildFailure{}, false
+}
+
+// isGenericError returns whether the given line is an error that doesn't point at a line.
+func isGenericError(line string) bool {
+	for _, re := range genericErrorRegexps {
+		if re.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// repoPath turns a file path as seen by the build into a path relative to the repository.
+func repoPath(file, sourceDir string) string {
+	file = strings.TrimPrefix(file, "/workspace/")
+	return path.Join(strings.TrimPrefix(sourceDir, "/"), file)
+}
+
+// reportFailure surfaces why the given deployment failed. The failed steps are taken from the
+// deployment's progress and failure signatures in the build logs are emitted as annotations.
+func (d *deployer) reportFailure(dep *godo.Deployment, spec *godo.AppSpec, logs map[string]*componentLogs) {
+	for _, step := range failedSteps(dep.GetProgress().GetSteps(), nil, "") {
+		d.action.Errorf("%s", step)
+	}
+
+	_ = godo.ForEachAppSpecComponent(spec, func(c godo.AppBuildableComponentSpec) error {
+		l := logs[c.GetName()]
+		if l == nil {
+			return nil
+		}
+		for _, f := range parseBuildFailures(l.BuildLogs, c.GetSourceDir()) {
+			fields := map[string]string{"title": fmt.Sprintf("Build of %s failed", c.GetName())}
+			if f.file != "" {
+				fields["file"] = f.file
+				fields["line"] = f.line
+				if f.col != "" {
+					fields["col"] = f.col
+				}
+			}
+			d.action.WithFieldsMap(fields).Errorf("%s", f.message)
+		}
+		return nil
+	})
+}
+
+// failedSteps returns descriptions of the innermost failed steps of a deployment's progress.
+func failedSteps(steps []*godo.DeploymentProgressStep, parents []string, component string) []string {
+	var res []string
+	for _, step := range steps {
+		if step.Status != godo.DeploymentProgressStepStatus_Error {
+			continue
+		}
+
+		names := append(parents[:len(parents):len(parents)], step.Name)
+		stepComponent := component
+		if step.ComponentName != "" {
+			stepComponent = step.ComponentName
+		}
+		if nested := failedSteps(step.Steps, names, stepComponent); len(nested) > 0 {
+			res = append(res, nested...)
+			continue
+		}
+
+		desc := fmt.Sprintf("deployment failed in step %q", strings.Join(names, " > "))
+		if stepComponent != "" {
+			desc = fmt.Sprintf("component %q failed in step %q", stepComponent, strings.Join(names, " > "))
+		}
+		if step.Reason != nil && step.Reason.Message != "" {
+			desc += ": " + step.Reason.Message
+		}
+		res = append(res, desc)
+	}
+	return res
+}
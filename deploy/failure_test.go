@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/digitalocean/godo"
+	gha "github.com/sethvargo/go-githubactions"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBuildFailures(t *testing.T) {
+	tests := []struct {
+		name      string
+		logs      string
+		sourceDir string
+		expected  []buildFailure
+	}{{
+		name: "go compiler errors",
+		logs: `web 2024-05-01T10:00:00.123Z building...
+web 2024-05-01T10:00:01.123Z ./main.go:12:3: undefined: foo
+web 2024-05-01T10:00:01.123Z /workspace/pkg/util.go:7:1: syntax error: unexpected }
+web 2024-05-01T10:00:01.123Z ./main.go:12:3: undefined: foo`,
+		sourceDir: "/api",
+		expected: []buildFailure{
+			{file: "api/main.go", line: "12", col: "3", message: "undefined: foo"},
+			{file: "api/pkg/util.go", line: "7", col: "1", message: "syntax error: unexpected }"},
+		},
+	}, {
+		name:     "typescript errors",
+		logs:     "src/index.ts(4,10): error TS2304: Cannot find name 'bar'.",
+		expected: []buildFailure{{file: "src/index.ts", line: "4", col: "10", message: "error TS2304: Cannot find name 'bar'."}},
+	}, {
+		name: "npm errors",
+		logs: `npm ERR! code ELIFECYCLE
+npm ERR! code ELIFECYCLE
+npm error missing script: build`,
+		expected: []buildFailure{
+			{message: "npm ERR! code ELIFECYCLE"},
+			{message: "npm error missing script: build"},
+		},
+	}, {
+		name:     "missing files",
+		logs:     `COPY failed: file not found in build context or excluded by .dockerignore: stat go.sum: file does not exist`,
+		expected: []buildFailure{{message: "COPY failed: file not found in build context or excluded by .dockerignore: stat go.sum: file does not exist"}},
+	}, {
+		name: "no failures",
+		logs: "all good\nbuild complete",
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, parseBuildFailures(test.logs, test.sourceDir))
+		})
+	}
+}
+
+func TestReportFailure(t *testing.T) {
+	spec := &godo.AppSpec{
+		Name:     "foo",
+		Services: []*godo.AppServiceSpec{{Name: "web", SourceDir: "/web"}, {Name: "api"}},
+	}
+	dep := &godo.Deployment{
+		Phase: godo.DeploymentPhase_Error,
+		Progress: &godo.DeploymentProgress{
+			Steps: []*godo.DeploymentProgressStep{{
+				Name:   "build",
+				Status: godo.DeploymentProgressStepStatus_Error,
+				Steps: []*godo.DeploymentProgressStep{{
+					Name:          "web",
+					ComponentName: "web",
+					Status:        godo.DeploymentProgressStepStatus_Error,
+					Steps: []*godo.DeploymentProgressStep{{
+						Name:   "build",
+						Status: godo.DeploymentProgressStepStatus_Error,
+						Reason: &godo.DeploymentProgressStepReason{Message: "build failed with exit code 1"},
+					}},
+				}, {
+					Name:          "api",
+					ComponentName: "api",
+					Status:        godo.DeploymentProgressStepStatus_Success,
+				}},
+			}, {
+				Name:   "deploy",
+				Status: godo.DeploymentProgressStepStatus_Pending,
+			}},
+		},
+	}
+	logs := map[string]*componentLogs{
+		"web": {BuildLogs: "./main.go:12:3: undefined: foo\n"},
+		"api": {BuildLogs: "build complete\n"},
+	}
+
+	var actionLogs bytes.Buffer
+	d := &deployer{action: gha.New(gha.WithWriter(&actionLogs))}
+	d.reportFailure(dep, spec, logs)
+
+	require.Equal(t, `::error::component "web" failed in step "build > web > build": build failed with exit code 1
+::error col=3,file=web/main.go,line=12,title=Build of web failed::undefined: foo
+`, actionLogs.String())
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/digitalocean/app_actions/utils"
+)
+
+// maxHealthCheckBodySize caps how much of a response body is checked against the patterns.
+const maxHealthCheckBodySize = 1 << 20
+
+// checkHealth polls all configured health check paths of the given live URL until they pass. A
+// path fails if it doesn't pass within the configured number of retries.
+func (d *deployer) checkHealth(ctx context.Context, liveURL string) error {
+	backoff := utils.Backoff{
+		Initial: d.inputs.healthCheckInterval,
+		Max:     d.inputs.healthCheckInterval,
+		Factor:  1,
+	}
+	for _, path := range d.inputs.healthCheckPaths {
+		url := strings.TrimSuffix(liveURL, "/") + "/" + strings.TrimPrefix(path, "/")
+
+		attempts := d.inputs.healthCheckRetries + 1
+		attempt := 0
+		var lastErr error
+		err := utils.Poll(ctx, backoff, func() (bool, error) {
+			attempt++
+			status, err := d.checkURL(ctx, url)
+			if err == nil {
+				d.action.Infof("health check of %s passed with status %d", url, status)
+				return true, nil
+			}
+			lastErr = err
+			d.action.Infof("health check of %s failed (attempt %d/%d): %v", url, attempt, attempts, err)
+			if attempt >= attempts {
+				return false, fmt.Errorf("health check of %s failed after %d attempts: %w", url, attempts, lastErr)
+			}
+			return false, nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkURL requests the given URL once and verifies its status code and body. It returns the
+// status code of the response.
+func (d *deployer) checkURL(ctx context.Context, url string) (int, error) {
+	ctx, cancel := withTimeout(ctx, d.inputs.healthCheckTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	codes := d.inputs.healthCheckStatusCodes
+	if len(codes) == 0 {
+		codes = []int{http.StatusOK}
+	}
+	if !slices.Contains(codes, resp.StatusCode) {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d, expected one of %v", resp.StatusCode, codes)
+	}
+	if len(d.inputs.healthCheckBodyRegex) == 0 {
+		return resp.StatusCode, nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxHealthCheckBodySize))
+	if err != nil {
+		return resp.StatusCode, fmt.Errorf("failed to read response body: %w", err)
+	}
+	for _, re := range d.inputs.healthCheckBodyRegex {
+		if !re.Match(body) {
+			return resp.StatusCode, fmt.Errorf("response body doesn't match %q", re.String())
+		}
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	gha "github.com/sethvargo/go-githubactions"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckHealth(t *testing.T) {
+	ctx := context.Background()
+
+	var readyRequests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			w.Write([]byte(`{"status":"ok"}`))
+		case "/ready":
+			// Only becomes ready on the second request.
+			readyRequests++
+			if readyRequests < 2 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		case "/degraded":
+			w.Write([]byte(`{"status":"degraded"}`))
+		case "/slow":
+			time.Sleep(100 * time.Millisecond)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name         string
+		inputs       inputs
+		expectedLogs string
+		err          bool
+	}{{
+		name: "passes",
+		inputs: inputs{
+			healthCheckPaths:       []string{"/health", "ready"},
+			healthCheckStatusCodes: []int{200, 204},
+			healthCheckRetries:     1,
+		},
+		expectedLogs: `health check of URL/health passed with status 200
+health check of URL/ready failed (attempt 1/2): unexpected status 503, expected one of [200 204]
+health check of URL/ready passed with status 204
+`,
+	}, {
+		name: "body matches",
+		inputs: inputs{
+			healthCheckPaths:     []string{"/health"},
+			healthCheckBodyRegex: []*regexp.Regexp{regexp.MustCompile(`"status":"ok"`)},
+		},
+		expectedLogs: `health check of URL/health passed with status 200
+`,
+	}, {
+		name: "body doesn't match",
+		inputs: inputs{
+			healthCheckPaths:     []string{"/degraded"},
+			healthCheckBodyRegex: []*regexp.Regexp{regexp.MustCompile(`"status":"ok"`)},
+			healthCheckRetries:   1,
+		},
+		expectedLogs: `health check of URL/degraded failed (attempt 1/2): response body doesn't match "\"status\":\"ok\""
+health check of URL/degraded failed (attempt 2/2): response body doesn't match "\"status\":\"ok\""
+`,
+		err: true,
+	}, {
+		name: "times out",
+		inputs: inputs{
+			healthCheckPaths:   []string{"/slow"},
+			healthCheckTimeout: 10 * time.Millisecond,
+		},
+		expectedLogs: `health check of URL/slow failed (attempt 1/1): failed to send request: Get "URL/slow": context deadline exceeded
+`,
+		err: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			readyRequests = 0
+
+			var actionLogs bytes.Buffer
+			test.inputs.healthCheckInterval = time.Millisecond
+			d := &deployer{
+				action:     gha.New(gha.WithWriter(&actionLogs)),
+				httpClient: srv.Client(),
+				inputs:     test.inputs,
+			}
+
+			err := d.checkHealth(ctx, srv.URL+"/")
+			if test.err {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, test.expectedLogs, strings.ReplaceAll(actionLogs.String(), srv.URL, "URL"))
+		})
+	}
+}
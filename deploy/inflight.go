@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/digitalocean/godo"
+)
+
+const (
+	// inFlightPolicyWait waits for in-flight deployments to finish.
+	inFlightPolicyWait = "wait"
+	// inFlightPolicyCancel cancels in-flight deployments.
+	inFlightPolicyCancel = "cancel"
+	// inFlightPolicyFail fails if there are in-flight deployments.
+	inFlightPolicyFail = "fail"
+)
+
+// inFlightPolicies are all supported policies for handling in-flight deployments.
+var inFlightPolicies = []string{inFlightPolicyWait, inFlightPolicyCancel, inFlightPolicyFail}
+
+// handleInFlightDeployments applies the configured policy to all of the given deployments that
+// are still in progress.
+func (d *deployer) handleInFlightDeployments(ctx context.Context, appID string, ds []*godo.Deployment) error {
+	for _, dep := range ds {
+		if !isInFlight(dep) {
+			continue
+		}
+
+		switch d.inputs.inFlightPolicy {
+		case inFlightPolicyFail:
+			return fmt.Errorf("deployment %s is still in progress in phase %s", dep.GetID(), dep.GetPhase())
+		case inFlightPolicyCancel:
+			d.action.Infof("deployment %s is still in progress, canceling it...", dep.GetID())
+			if _, _, err := d.deployments.CancelDeployment(ctx, appID, dep.GetID()); err != nil {
+				return fmt.Errorf("failed to cancel deployment %s: %w", dep.GetID(), err)
+			}
+		default:
+			d.action.Infof("deployment %s is still in progress, waiting for it to finish...", dep.GetID())
+		}
+
+		// The deployment might not have been caused by this run, so don't cancel it if this run is
+		// canceled while waiting.
+		if _, err := d.watchDeployment(ctx, appID, dep.GetID(), nil); err != nil {
+			return fmt.Errorf("failed to wait for deployment %s to finish: %w", dep.GetID(), err)
+		}
+	}
+	return nil
+}
+
+// isInFlight returns whether or not the given deployment is still in progress.
+func isInFlight(d *godo.Deployment) bool {
+	switch d.GetPhase() {
+	case godo.DeploymentPhase_PendingBuild, godo.DeploymentPhase_Building, godo.DeploymentPhase_PendingDeploy, godo.DeploymentPhase_Deploying:
+		return true
+	}
+	return false
+}
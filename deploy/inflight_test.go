@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/digitalocean/godo"
+	gha "github.com/sethvargo/go-githubactions"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleInFlightDeployments(t *testing.T) {
+	ctx := context.Background()
+	appID := "app-id"
+	building := &godo.Deployment{ID: "building-id", Phase: godo.DeploymentPhase_Building}
+	active := &godo.Deployment{ID: "active-id", Phase: godo.DeploymentPhase_Active}
+
+	tests := []struct {
+		name         string
+		existing     []*godo.Deployment
+		policy       string
+		appService   *mockedAppsService
+		deployments  *mockedDeploymentService
+		expectedLogs string
+		err          bool
+	}{{
+		name:        "no in-flight deployments",
+		existing:    []*godo.Deployment{active},
+		policy:      inFlightPolicyFail,
+		appService:  &mockedAppsService{},
+		deployments: &mockedDeploymentService{},
+	}, {
+		name:        "waits for in-flight deployments",
+		existing:    []*godo.Deployment{building, active},
+		policy:      inFlightPolicyWait,
+		appService:  appServiceWithDeployment(ctx, appID, building.ID, godo.DeploymentPhase_Active),
+		deployments: &mockedDeploymentService{},
+		expectedLogs: `deployment building-id is still in progress, waiting for it to finish...
+deployment is in phase: ACTIVE
+`,
+	}, {
+		name:       "cancels in-flight deployments",
+		existing:   []*godo.Deployment{building, active},
+		policy:     inFlightPolicyCancel,
+		appService: appServiceWithDeployment(ctx, appID, building.ID, godo.DeploymentPhase_Canceled),
+		deployments: func() *mockedDeploymentService {
+			ds := &mockedDeploymentService{}
+			ds.On("CancelDeployment", ctx, appID, building.ID).Return(&godo.Deployment{}, &godo.Response{}, nil)
+			return ds
+		}(),
+		expectedLogs: `deployment building-id is still in progress, canceling it...
+deployment is in phase: CANCELED
+`,
+	}, {
+		name:        "fails on in-flight deployments",
+		existing:    []*godo.Deployment{building, active},
+		policy:      inFlightPolicyFail,
+		appService:  &mockedAppsService{},
+		deployments: &mockedDeploymentService{},
+		err:         true,
+	}, {
+		name:       "fails to cancel in-flight deployments",
+		existing:   []*godo.Deployment{building, active},
+		policy:     inFlightPolicyCancel,
+		appService: &mockedAppsService{},
+		deployments: func() *mockedDeploymentService {
+			ds := &mockedDeploymentService{}
+			ds.On("CancelDeployment", ctx, appID, building.ID).Return(&godo.Deployment{}, &godo.Response{}, errors.New("an error"))
+			return ds
+		}(),
+		expectedLogs: `deployment building-id is still in progress, canceling it...
+`,
+		err: true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var actionLogs bytes.Buffer
+			d := &deployer{
+				action:      gha.New(gha.WithWriter(&actionLogs)),
+				apps:        test.appService,
+				deployments: test.deployments,
+				inputs:      inputs{inFlightPolicy: test.policy},
+			}
+
+			err := d.handleInFlightDeployments(ctx, appID, test.existing)
+			if test.err {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, test.expectedLogs, actionLogs.String())
+
+			test.appService.AssertExpectations(t)
+			test.deployments.AssertExpectations(t)
+		})
+	}
+}
+
+func TestHandleInFlightDeploymentsDoesNotCancelOnCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	appID := "app-id"
+	building := &godo.Deployment{ID: "building-id", Phase: godo.DeploymentPhase_Building}
+
+	as := &mockedAppsService{}
+	// Cancel the context while waiting for the other deployment.
+	as.On("GetDeployment", ctx, appID, building.ID).Return(building, &godo.Response{}, nil).Run(func(mock.Arguments) { cancel() })
+	// The deployment wasn't caused by this run, so it must not be canceled.
+	ds := &mockedDeploymentService{}
+
+	var actionLogs bytes.Buffer
+	d := &deployer{
+		action:      gha.New(gha.WithWriter(&actionLogs)),
+		apps:        as,
+		deployments: ds,
+		inputs:      inputs{inFlightPolicy: inFlightPolicyWait},
+	}
+
+	err := d.handleInFlightDeployments(ctx, appID, []*godo.Deployment{building})
+	require.ErrorIs(t, err, context.Canceled)
+	require.Equal(t, `deployment building-id is still in progress, waiting for it to finish...
+deployment is in phase: BUILDING
+`, actionLogs.String())
+
+	as.AssertExpectations(t)
+	ds.AssertNotCalled(t, "CancelDeployment", mock.Anything, mock.Anything, mock.Anything)
+}
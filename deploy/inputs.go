@@ -1,18 +1,46 @@
 package main
 
 import (
+	"regexp"
+	"time"
+
 	"github.com/digitalocean/app_actions/utils"
 	gha "github.com/sethvargo/go-githubactions"
 )
 
 // inputs are the inputs for the action.
 type inputs struct {
-	token           string
-	appSpecLocation string
-	appName         string
-	printBuildLogs  bool
-	printDeployLogs bool
-	deployPRPreview bool
+	token                      string
+	appSpecLocations           []string
+	appSpecLocation            string
+	appName                    string
+	printBuildLogs             bool
+	printDeployLogs            bool
+	deployPRPreview            bool
+	validateOnly               bool
+	dryRun                     bool
+	rollbackOnFailure          bool
+	deploymentTimeout          time.Duration
+	liveURLTimeout             time.Duration
+	inFlightPolicy             string
+	forceRebuild               bool
+	tailRunLogs                time.Duration
+	runLogErrorPatterns        []*regexp.Regexp
+	healthCheckPaths           []string
+	healthCheckStatusCodes     []int
+	healthCheckBodyRegex       []*regexp.Regexp
+	healthCheckRetries         int
+	healthCheckInterval        time.Duration
+	healthCheckTimeout         time.Duration
+	smokeTestCommand           string
+	smokeTestTimeout           time.Duration
+	rollbackOnSmokeTestFailure bool
+	parallelism                int
+	appSpecTemplating          string
+	strictEnvExpansion         bool
+	appSpecOverlays            []string
+	appSpec                    string
+	appNameOverride            string
 }
 
 // getInputs gets the inputs for the action.
@@ -20,11 +48,35 @@ func getInputs(a *gha.Action) (inputs, error) {
 	var in inputs
 	for _, err := range []error{
 		utils.InputAsString(a, "token", true, &in.token),
-		utils.InputAsString(a, "app_spec_location", false, &in.appSpecLocation),
+		utils.InputAsList(a, "app_spec_location", false, &in.appSpecLocations),
 		utils.InputAsString(a, "app_name", false, &in.appName),
 		utils.InputAsBool(a, "print_build_logs", true, &in.printBuildLogs),
 		utils.InputAsBool(a, "print_deploy_logs", true, &in.printDeployLogs),
 		utils.InputAsBool(a, "deploy_pr_preview", true, &in.deployPRPreview),
+		utils.InputAsBool(a, "validate_only", true, &in.validateOnly),
+		utils.InputAsBool(a, "dry_run", true, &in.dryRun),
+		utils.InputAsBool(a, "rollback_on_failure", true, &in.rollbackOnFailure),
+		utils.InputAsDuration(a, "deployment_timeout", false, &in.deploymentTimeout),
+		utils.InputAsDuration(a, "live_url_timeout", false, &in.liveURLTimeout),
+		utils.InputAsOneOf(a, "in_flight_policy", false, inFlightPolicies, &in.inFlightPolicy),
+		utils.InputAsBool(a, "force_rebuild", true, &in.forceRebuild),
+		utils.InputAsDuration(a, "tail_run_logs", false, &in.tailRunLogs),
+		utils.InputAsRegexps(a, "run_log_error_patterns", false, &in.runLogErrorPatterns),
+		utils.InputAsList(a, "health_check_paths", false, &in.healthCheckPaths),
+		utils.InputAsInts(a, "health_check_status_codes", false, &in.healthCheckStatusCodes),
+		utils.InputAsRegexps(a, "health_check_body_regex", false, &in.healthCheckBodyRegex),
+		utils.InputAsInt(a, "health_check_retries", false, &in.healthCheckRetries),
+		utils.InputAsDuration(a, "health_check_interval", false, &in.healthCheckInterval),
+		utils.InputAsDuration(a, "health_check_timeout", false, &in.healthCheckTimeout),
+		utils.InputAsString(a, "smoke_test_command", false, &in.smokeTestCommand),
+		utils.InputAsDuration(a, "smoke_test_timeout", false, &in.smokeTestTimeout),
+		utils.InputAsBool(a, "rollback_on_smoke_test_failure", true, &in.rollbackOnSmokeTestFailure),
+		utils.InputAsInt(a, "parallelism", false, &in.parallelism),
+		utils.InputAsOneOf(a, "app_spec_templating", false, specTemplatings, &in.appSpecTemplating),
+		utils.InputAsBool(a, "strict_env_expansion", true, &in.strictEnvExpansion),
+		utils.InputAsList(a, "app_spec_overlays", false, &in.appSpecOverlays),
+		utils.InputAsString(a, "app_spec", false, &in.appSpec),
+		utils.InputAsString(a, "app_name_override", false, &in.appNameOverride),
 	} {
 		if err != nil {
 			return in, err
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/digitalocean/godo"
+)
+
+// logStreamGracePeriod is how long a log stream may continue after the deployment left the
+// respective phase. The last lines usually hold the error if the deployment failed.
+const logStreamGracePeriod = 10 * time.Second
+
+// logStreamer follows the live logs of a deployment while it's building and deploying. The logs
+// are streamed in the background to not hold up polling the deployment.
+type logStreamer struct {
+	d            *deployer
+	ctx          context.Context
+	appID        string
+	deploymentID string
+	// gracePeriod is how long a stream may continue after the deployment left its phase.
+	gracePeriod time.Duration
+	// streams are the streams that have been started, by log type.
+	streams map[godo.AppLogType]*logStream
+	wg      sync.WaitGroup
+}
+
+// logStream is a single stream of live logs.
+type logStream struct {
+	// phase is the phase of the deployment the logs belong to.
+	phase godo.DeploymentPhase
+	// stop stops the stream.
+	stop context.CancelFunc
+	// stopTimer stops the stream once the grace period passed, if the deployment moved on.
+	stopTimer *time.Timer
+}
+
+// newLogStreamer returns a logStreamer for the given deployment. The streams are bound to the
+// given context.
+func (d *deployer) newLogStreamer(ctx context.Context, appID, deploymentID string) *logStreamer {
+	return &logStreamer{
+		d:            d,
+		ctx:          ctx,
+		appID:        appID,
+		deploymentID: deploymentID,
+		gracePeriod:  logStreamGracePeriod,
+		streams:      make(map[godo.AppLogType]*logStream),
+	}
+}
+
+// onUpdate starts following the logs matching the deployment's current phase, if printing them is
+// enabled, and stops the streams of the phases the deployment moved on from. It doesn't wait for
+// the logs to be streamed.
+func (s *logStreamer) onUpdate(dep *godo.Deployment) {
+	s.stopStreams(dep.GetPhase())
+
+	switch dep.GetPhase() {
+	case godo.DeploymentPhase_Building:
+		if s.d.inputs.printBuildLogs {
+			s.follow(godo.AppLogTypeBuild, dep.GetPhase())
+		}
+	case godo.DeploymentPhase_Deploying:
+		if s.d.inputs.printDeployLogs {
+			s.follow(godo.AppLogTypeDeploy, dep.GetPhase())
+		}
+	}
+}
+
+// wait stops all streams once the grace period passed and waits for them to end.
+func (s *logStreamer) wait() {
+	s.stopStreams("")
+	s.wg.Wait()
+	for _, stream := range s.streams {
+		stream.stopTimer.Stop()
+	}
+}
+
+// stopStreams stops all streams that don't belong to the given phase once the grace period passed.
+// The streams aren't guaranteed to end on their own.
+func (s *logStreamer) stopStreams(phase godo.DeploymentPhase) {
+	for _, stream := range s.streams {
+		if stream.phase != phase && stream.stopTimer == nil {
+			stream.stopTimer = time.AfterFunc(s.gracePeriod, stream.stop)
+		}
+	}
+}
+
+// follow starts printing the live logs of the given type in the background as they arrive, until
+// the deployment left the given phase and the grace period passed. The lines are prefixed with
+// the log type rather than grouped, as they're interleaved with the deployment's progress and the
+// complete logs are printed into a group per component by reportLogs once the deployment
+// finished. Failures are therefore only surfaced as warnings.
+func (s *logStreamer) follow(typ godo.AppLogType, phase godo.DeploymentPhase) {
+	if s.streams[typ] != nil {
+		return
+	}
+
+	logs, resp, err := s.d.apps.GetLogs(s.ctx, s.appID, s.deploymentID, "", typ, true, -1)
+	if err != nil {
+		// Ignore if we get a 400, as this means the logs are not available yet.
+		if resp == nil || resp.StatusCode != http.StatusBadRequest {
+			s.d.action.Warningf("failed to get live %s logs: %v", logTypeName(typ), err)
+		}
+		return
+	}
+	if logs.LiveURL == "" {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	s.streams[typ] = &logStream{phase: phase, stop: cancel}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer cancel()
+
+		name := logTypeName(typ)
+		err := s.d.streamLogs(ctx, logs.LiveURL, func(line string) {
+			s.d.action.Infof("[%s] %s", name, line)
+		})
+		switch {
+		case err == nil:
+		case ctx.Err() != nil:
+			s.d.action.Infof("stopped streaming %s logs as the deployment moved on", name)
+		default:
+			s.d.action.Warningf("failed to stream %s logs: %v", name, err)
+		}
+	}()
+}
+
+// componentLogs are the logs of a single component.
+type componentLogs struct {
+	BuildLogs  string `json:"build_logs,omitempty"`
+	DeployLogs string `json:"deploy_logs,omitempty"`
+}
+
+// reportLogs fetches the build and deploy logs of every component of the given spec. They're
+// surfaced as outputs and, if printing them is enabled, printed into a group per component. This
+// happens regardless of whether they've been streamed live, as the stream interleaves all
+// components and might have been cut off. The logs are returned by component name.
+func (d *deployer) reportLogs(ctx context.Context, appID, deploymentID string, spec *godo.AppSpec) (map[string]*componentLogs, error) {
+	components := make(map[string]*componentLogs)
+	for _, typ := range []godo.AppLogType{godo.AppLogTypeBuild, godo.AppLogTypeDeploy} {
+		print := d.inputs.printBuildLogs
+		if typ == godo.AppLogTypeDeploy {
+			print = d.inputs.printDeployLogs
+		}
+
+		var all bytes.Buffer
+		for _, component := range logComponents(spec, typ) {
+			logs, err := d.getLogs(ctx, appID, deploymentID, component, typ)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get %s logs of component %q: %w", logTypeName(typ), component, err)
+			}
+			if len(logs) == 0 {
+				continue
+			}
+			all.Write(logs)
+
+			if components[component] == nil {
+				components[component] = &componentLogs{}
+			}
+			if typ == godo.AppLogTypeBuild {
+				components[component].BuildLogs = string(logs)
+			} else {
+				components[component].DeployLogs = string(logs)
+			}
+
+			if print {
+				d.action.Group(fmt.Sprintf("%s logs (%s)", logTypeName(typ), component))
+				d.action.Infof(string(logs))
+				d.action.EndGroup()
+			}
+		}
+		if all.Len() > 0 {
+			d.action.SetOutput(logTypeName(typ)+"_logs", all.String())
+		}
+	}
+
+	if len(components) > 0 {
+		componentsJSON, err := json.Marshal(components)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal component logs: %w", err)
+		}
+		d.action.SetOutput("component_logs", string(componentsJSON))
+	}
+	return components, nil
+}
+
+// logComponents returns the names of the components in the given spec that produce logs of the
+// given type. Only buildable components have build logs and only containers have deploy logs.
+func logComponents(spec *godo.AppSpec, typ godo.AppLogType) []string {
+	var names []string
+	if typ == godo.AppLogTypeBuild {
+		_ = godo.ForEachAppSpecComponent(spec, func(c godo.AppBuildableComponentSpec) error {
+			names = append(names, c.GetName())
+			return nil
+		})
+	} else {
+		_ = godo.ForEachAppSpecComponent(spec, func(c godo.AppContainerComponentSpec) error {
+			names = append(names, c.GetName())
+			return nil
+		})
+	}
+	return names
+}
+
+// logTypeName returns the human readable name of the given log type.
+func logTypeName(typ godo.AppLogType) string {
+	return strings.ToLower(string(typ))
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/digitalocean/godo"
+	gha "github.com/sethvargo/go-githubactions"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogStreamer(t *testing.T) {
+	ctx := context.Background()
+	appID := "app-id"
+	deploymentID := "deployment-id"
+
+	tests := []struct {
+		name         string
+		phase        godo.DeploymentPhase
+		appService   func(liveURL string) *mockedAppsService
+		liveLogs     []string
+		logsRT       *mockedRoundtripper
+		inputs       inputs
+		expectedLogs string
+	}{{
+		name:  "streams build logs",
+		phase: godo.DeploymentPhase_Building,
+		appService: func(liveURL string) *mockedAppsService {
+			as := &mockedAppsService{}
+			as.On("GetLogs", ctx, appID, deploymentID, "", godo.AppLogTypeBuild, true, -1).Return(&godo.AppLogs{
+				LiveURL: liveURL,
+			}, &godo.Response{}, nil)
+			return as
+		},
+		liveLogs: []string{"line 1\n", "line 2"},
+		inputs:   inputs{printBuildLogs: true},
+		expectedLogs: `[build] line 1
+[build] line 2
+`,
+	}, {
+		name:  "streams deploy logs",
+		phase: godo.DeploymentPhase_Deploying,
+		appService: func(liveURL string) *mockedAppsService {
+			as := &mockedAppsService{}
+			as.On("GetLogs", ctx, appID, deploymentID, "", godo.AppLogTypeDeploy, true, -1).Return(&godo.AppLogs{
+				LiveURL: liveURL,
+			}, &godo.Response{}, nil)
+			return as
+		},
+		liveLogs: []string{"deploying\n"},
+		inputs:   inputs{printDeployLogs: true},
+		expectedLogs: `[deploy] deploying
+`,
+	}, {
+		name:       "doesn't stream if printing is disabled",
+		phase:      godo.DeploymentPhase_Building,
+		appService: func(string) *mockedAppsService { return &mockedAppsService{} },
+	}, {
+		name:  "ignores logs that aren't available yet",
+		phase: godo.DeploymentPhase_Building,
+		appService: func(string) *mockedAppsService {
+			as := &mockedAppsService{}
+			as.On("GetLogs", ctx, appID, deploymentID, "", godo.AppLogTypeBuild, true, -1).Return(&godo.AppLogs{}, &godo.Response{Response: &http.Response{StatusCode: http.StatusBadRequest}}, errors.New("an error"))
+			return as
+		},
+		inputs: inputs{printBuildLogs: true},
+	}, {
+		name:  "warns if streaming fails",
+		phase: godo.DeploymentPhase_Building,
+		appService: func(liveURL string) *mockedAppsService {
+			as := &mockedAppsService{}
+			as.On("GetLogs", ctx, appID, deploymentID, "", godo.AppLogTypeBuild, true, -1).Return(&godo.AppLogs{
+				LiveURL: liveURL,
+			}, &godo.Response{}, nil)
+			return as
+		},
+		logsRT: func() *mockedRoundtripper {
+			rt := &mockedRoundtripper{}
+			rt.On("RoundTrip", mock.Anything).Return(&http.Response{
+				StatusCode: http.StatusUnauthorized,
+				Status:     "401 Unauthorized",
+				Body:       io.NopCloser(strings.NewReader("token expired")),
+			}, nil)
+			return rt
+		}(),
+		inputs: inputs{printBuildLogs: true},
+		expectedLogs: `::warning::failed to stream build logs: failed to get live logs: unexpected status 401 Unauthorized
+`,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			srv := newLogServer(t, logChunks(test.liveLogs...))
+			as := test.appService(srv.URL)
+			client := srv.Client()
+			if test.logsRT != nil {
+				client = &http.Client{Transport: test.logsRT}
+			}
+
+			var actionLogs bytes.Buffer
+			d := &deployer{
+				action:     gha.New(gha.WithWriter(&actionLogs)),
+				apps:       as,
+				httpClient: client,
+				inputs:     test.inputs,
+			}
+
+			s := d.newLogStreamer(ctx, appID, deploymentID)
+			s.onUpdate(&godo.Deployment{Phase: test.phase})
+			// Logs are only streamed once.
+			s.onUpdate(&godo.Deployment{Phase: test.phase})
+			s.wait()
+
+			require.Equal(t, test.expectedLogs, actionLogs.String())
+			as.AssertExpectations(t)
+			if test.logsRT != nil {
+				test.logsRT.AssertExpectations(t)
+			}
+		})
+	}
+}
+
+func TestLogStreamerStopsOnceDeploymentMovedOn(t *testing.T) {
+	ctx := context.Background()
+	appID := "app-id"
+	deploymentID := "deployment-id"
+
+	// The stream only ends once the client goes away.
+	chunks := make(chan string, 1)
+	chunks <- "line 1\n"
+	srv := newLogServer(t, chunks)
+
+	as := &mockedAppsService{}
+	as.On("GetLogs", ctx, appID, deploymentID, "", godo.AppLogTypeBuild, true, -1).Return(&godo.AppLogs{
+		LiveURL: srv.URL,
+	}, &godo.Response{}, nil)
+
+	var actionLogs bytes.Buffer
+	w := &syncWriter{w: &actionLogs}
+	d := &deployer{
+		action:     gha.New(gha.WithWriter(w)),
+		apps:       as,
+		httpClient: srv.Client(),
+		inputs:     inputs{printBuildLogs: true},
+	}
+
+	s := d.newLogStreamer(ctx, appID, deploymentID)
+	s.gracePeriod = 0
+	s.onUpdate(&godo.Deployment{Phase: godo.DeploymentPhase_Building})
+	require.Eventually(t, func() bool {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		return strings.Contains(actionLogs.String(), "line 1")
+	}, time.Second, 10*time.Millisecond)
+	s.onUpdate(&godo.Deployment{Phase: godo.DeploymentPhase_Deploying})
+	s.wait()
+
+	require.Equal(t, `[build] line 1
+stopped streaming build logs as the deployment moved on
+`, actionLogs.String())
+	as.AssertExpectations(t)
+}
+
+func TestReportLogs(t *testing.T) {
+	ctx := context.Background()
+	appID := "app-id"
+	deploymentID := "deployment-id"
+	spec := &godo.AppSpec{
+		Name:        "foo",
+		Services:    []*godo.AppServiceSpec{{Name: "web"}},
+		StaticSites: []*godo.AppStaticSiteSpec{{Name: "site"}},
+		Databases:   []*godo.AppDatabaseSpec{{Name: "db"}},
+	}
+
+	as := &mockedAppsService{}
+	for _, l := range []struct {
+		component string
+		typ       godo.AppLogType
+		url       string
+	}{
+		{"web", godo.AppLogTypeBuild, "http://web-build.com"},
+		{"site", godo.AppLogTypeBuild, "http://site-build.com"},
+		{"web", godo.AppLogTypeDeploy, "http://web-deploy.com"},
+	} {
+		as.On("GetLogs", ctx, appID, deploymentID, l.component, l.typ, true, -1).Return(&godo.AppLogs{
+			HistoricURLs: []string{l.url},
+		}, &godo.Response{}, nil)
+	}
+	rt := &mockedRoundtripper{}
+	for _, body := range []string{"web build\n", "site build\n", "web deploy\n"} {
+		rt.On("RoundTrip", mock.Anything).Return(&http.Response{
+			Body: io.NopCloser(bytes.NewReader([]byte(body))),
+		}, nil).Once()
+	}
+
+	var actionLogs bytes.Buffer
+	outputFilePath := t.TempDir() + "/output"
+	d := &deployer{
+		action: gha.New(gha.WithWriter(&actionLogs), gha.WithGetenv(func(k string) string {
+			if k == "GITHUB_OUTPUT" {
+				return outputFilePath
+			}
+			return ""
+		})),
+		apps:       as,
+		httpClient: &http.Client{Transport: rt},
+		inputs:     inputs{printBuildLogs: true, printDeployLogs: true},
+	}
+
+	logs, err := d.reportLogs(ctx, appID, deploymentID, spec)
+	require.NoError(t, err)
+	require.Equal(t, map[string]*componentLogs{
+		"web":  {BuildLogs: "web build\n", DeployLogs: "web deploy\n"},
+		"site": {BuildLogs: "site build\n"},
+	}, logs)
+	require.Equal(t, `::group::build logs (web)
+web build
+
+::endgroup::
+::group::build logs (site)
+site build
+
+::endgroup::
+::group::deploy logs (web)
+web deploy
+
+::endgroup::
+`, actionLogs.String())
+
+	output, err := os.ReadFile(outputFilePath)
+	require.NoError(t, err)
+	require.Equal(t, `build_logs<<_GitHubActionsFileCommandDelimeter_
+web build
+site build
+
+_GitHubActionsFileCommandDelimeter_
+deploy_logs<<_GitHubActionsFileCommandDelimeter_
+web deploy
+
+_GitHubActionsFileCommandDelimeter_
+component_logs<<_GitHubActionsFileCommandDelimeter_
+{"site":{"build_logs":"site build\n"},"web":{"build_logs":"web build\n","deploy_logs":"web deploy\n"}}
+_GitHubActionsFileCommandDelimeter_
+`, string(output))
+
+	as.AssertExpectations(t)
+	rt.AssertExpectations(t)
+}
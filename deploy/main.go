@@ -3,11 +3,13 @@ package main
 import (
 	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/digitalocean/app_actions/utils"
@@ -16,8 +18,13 @@ import (
 	"sigs.k8s.io/yaml"
 )
 
+// exitCodeCanceled is the exit code used if the action was canceled.
+const exitCodeCanceled = 130
+
 func main() {
-	ctx := context.Background()
+	// GitHub sends SIGINT and SIGTERM if the workflow is canceled.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 	a := gha.New()
 
 	in, err := getInputs(a)
@@ -27,57 +34,69 @@ func main() {
 	// Mask the DO token to avoid accidentally leaking it.
 	a.AddMask(in.token)
 
+	do := godo.NewFromToken(in.token)
 	d := &deployer{
-		action:     a,
-		apps:       godo.NewFromToken(in.token).Apps,
-		httpClient: http.DefaultClient,
-		inputs:     in,
-	}
-
-	spec, err := d.createSpec(ctx)
-	if err != nil {
-		a.Fatalf("failed to create spec: %v", err)
+		action:      a,
+		apps:        do.Apps,
+		deployments: utils.NewDeploymentService(do),
+		rollbacks:   utils.NewRollbackService(do),
+		httpClient:  http.DefaultClient,
+		inputs:      in,
 	}
 
-	if in.deployPRPreview {
-		ghCtx, err := a.Context()
+	if in.appName == "" && in.appSpec == "" {
+		locations, err := resolveSpecLocations(in.appSpecLocations)
 		if err != nil {
-			a.Fatalf("failed to get GitHub context: %v", err)
+			a.Fatalf("failed to resolve app spec location: %v", err)
 		}
-
-		// If this is a PR preview, we need to sanitize the spec.
-		if err := utils.SanitizeSpecForPullRequestPreview(spec, ghCtx); err != nil {
-			a.Fatalf("failed to sanitize spec for PR preview: %v", err)
+		if len(locations) > 1 {
+			if err := d.deployApps(ctx, locations); err != nil {
+				if ctx.Err() != nil {
+					a.Errorf("deployment was canceled: %v", err)
+					os.Exit(exitCodeCanceled)
+				}
+				a.Fatalf("%v", err)
+			}
+			return
 		}
+		d.inputs.appSpecLocation = locations[0]
 	}
 
-	app, err := d.deploy(ctx, spec)
-	if app != nil {
-		// Surface a JSON representation of the app regardless of success or failure.
-		appJSON, err := json.Marshal(app)
-		if err != nil {
-			a.Errorf("failed to marshal app: %v", err)
+	_, res, err := d.run(ctx)
+	if res != nil && res.app != nil {
+		// Surface the app's state regardless of success or failure.
+		if err := d.setOutputs(res); err != nil {
+			a.Errorf("failed to set outputs: %v", err)
 		}
-		a.SetOutput("app", string(appJSON))
+		d.writeSummary(res.app, res.deployment)
 	}
 	if err != nil {
-		a.Fatalf("failed to deploy: %v", err)
+		if ctx.Err() != nil {
+			a.Errorf("deployment was canceled: %v", err)
+			os.Exit(exitCodeCanceled)
+		}
+		a.Fatalf("%v", err)
+	}
+	if res != nil {
+		a.Infof("App is now live under URL: %s", res.app.GetLiveURL())
 	}
-	a.Infof("App is now live under URL: %s", app.GetLiveURL())
 }
 
 // deployer is responsible for deploying the app.
 type deployer struct {
-	action     *gha.Action
-	apps       godo.AppsService
-	httpClient *http.Client
-	inputs     inputs
+	action      *gha.Action
+	apps        godo.AppsService
+	deployments utils.DeploymentService
+	rollbacks   utils.RollbackService
+	httpClient  *http.Client
+	inputs      inputs
 }
 
 func (d *deployer) createSpec(ctx context.Context) (*godo.AppSpec, error) {
-	// First, fetch the app spec either from a pre-existing app or from the file system.
+	// First, fetch the app spec either from a pre-existing app, the inputs or from the file system.
 	var spec *godo.AppSpec
-	if d.inputs.appName != "" {
+	switch {
+	case d.inputs.appName != "":
 		app, err := utils.FindAppByName(ctx, d.apps, d.inputs.appName)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get app: %w", err)
@@ -86,37 +105,159 @@ func (d *deployer) createSpec(ctx context.Context) (*godo.AppSpec, error) {
 			return nil, fmt.Errorf("app %q does not exist", d.inputs.appName)
 		}
 		spec = app.Spec
-	} else {
+
+		if d.inputs.appNameOverride != "" && d.inputs.appNameOverride != spec.GetName() {
+			// Domains can't be shared between apps, so don't carry them over to the copy.
+			spec.Domains = nil
+		}
+	case d.inputs.appSpec != "":
+		var err error
+		spec, err = d.parseSpec("", []byte(d.inputs.appSpec))
+		if err != nil {
+			return nil, err
+		}
+	default:
 		appSpec, err := os.ReadFile(d.inputs.appSpecLocation)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get app spec content: %w", err)
 		}
-		appSpecExpanded := os.ExpandEnv(string(appSpec))
-		if err := yaml.Unmarshal([]byte(appSpecExpanded), &spec); err != nil {
-			return nil, fmt.Errorf("failed to parse app spec: %w", err)
+		spec, err = d.parseSpec(d.inputs.appSpecLocation, appSpec)
+		if err != nil {
+			return nil, err
 		}
 	}
 
+	if d.inputs.appNameOverride != "" {
+		spec.Name = d.inputs.appNameOverride
+	}
+
 	if err := replaceImagesInSpec(spec); err != nil {
 		return nil, fmt.Errorf("failed to replace images in spec: %w", err)
 	}
 	return spec, nil
 }
 
-// deploy deploys the app and waits for it to be live.
-func (d *deployer) deploy(ctx context.Context, spec *godo.AppSpec) (*godo.App, error) {
-	// Either create or update the app.
-	app, err := utils.FindAppByName(ctx, d.apps, spec.GetName())
+// parseSpec expands the given raw app spec, validates it against the schema, applies the
+// configured overlays and parses it. The spec can be either YAML or JSON. The file it was read
+// from is empty if it was given inline.
+func (d *deployer) parseSpec(file string, content []byte) (*godo.AppSpec, error) {
+	name := file
+	if name == "" {
+		name = "app_spec"
+	}
+	expanded, err := d.expandSpec(name, content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expand app spec: %w", err)
+	}
+	if err := d.checkSpecSchema(file, content, expanded, false); err != nil {
+		return nil, err
+	}
+	if len(d.inputs.appSpecOverlays) > 0 {
+		expanded, err = d.applyOverlays(expanded)
+		if err != nil {
+			return nil, fmt.Errorf("failed to apply overlays: %w", err)
+		}
+	}
+
+	var spec *godo.AppSpec
+	if err := yaml.Unmarshal(expanded, &spec); err != nil {
+		return nil, fmt.Errorf("failed to parse app spec: %w", err)
+	}
+	if spec == nil {
+		return nil, fmt.Errorf("app spec %s is empty", name)
+	}
+	return spec, nil
+}
+
+// run creates the app spec and, depending on the inputs, plans, validates or deploys it. It
+// returns the spec and, if the app was deployed, the result of the deployment.
+func (d *deployer) run(ctx context.Context) (*godo.AppSpec, *deployResult, error) {
+	spec, err := d.createSpec(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get app: %w", err)
+		return nil, nil, fmt.Errorf("failed to create spec: %w", err)
+	}
+	return d.runSpec(ctx, spec)
+}
+
+// runSpec plans, validates or deploys the given app spec, depending on the inputs.
+func (d *deployer) runSpec(ctx context.Context, spec *godo.AppSpec) (*godo.AppSpec, *deployResult, error) {
+	if d.inputs.deployPRPreview {
+		ghCtx, err := d.action.Context()
+		if err != nil {
+			return spec, nil, fmt.Errorf("failed to get GitHub context: %w", err)
+		}
+
+		// If this is a PR preview, we need to sanitize the spec.
+		if err := utils.SanitizeSpecForPullRequestPreview(spec, ghCtx); err != nil {
+			return spec, nil, fmt.Errorf("failed to sanitize spec for PR preview: %w", err)
+		}
 	}
-	if app == nil {
+
+	if d.inputs.dryRun {
+		if err := d.dryRun(ctx, spec); err != nil {
+			return spec, nil, fmt.Errorf("failed to plan deployment: %w", err)
+		}
+		return spec, nil, nil
+	}
+
+	if d.inputs.validateOnly {
+		app, err := utils.FindAppByName(ctx, d.apps, spec.GetName())
+		if err != nil {
+			return spec, nil, fmt.Errorf("failed to get app: %w", err)
+		}
+		if _, err := d.validateSpec(ctx, spec, app.GetID()); err != nil {
+			return spec, nil, fmt.Errorf("failed to validate spec: %w", err)
+		}
+		d.action.Infof("validate_only is set, skipping deployment")
+		return spec, nil, nil
+	}
+
+	res, err := d.deploy(ctx, spec)
+	if err != nil {
+		return spec, res, fmt.Errorf("failed to deploy: %w", err)
+	}
+	return spec, res, nil
+}
+
+// deployResult is the outcome of deploying an app.
+type deployResult struct {
+	// app is the latest known state of the app.
+	app *godo.App
+	// deployment is the deployment caused by deploying the app.
+	deployment *godo.Deployment
+	// created is whether the app was created rather than updated.
+	created bool
+}
+
+// deploy deploys the app and waits for it to be live. The result is also returned alongside
+// errors if the app has been created or updated already.
+func (d *deployer) deploy(ctx context.Context, spec *godo.AppSpec) (*deployResult, error) {
+	// Resolve whether to create or update the app.
+	app, _, err := d.plan(ctx, spec)
+	if err != nil {
+		return nil, err
+	}
+
+	// Remember the deployments that already exist to tell them apart from the one we're causing
+	// and the currently active one to be able to roll back to it.
+	var previous []*godo.Deployment
+	previousActive := app.GetActiveDeployment()
+	created := app == nil
+	if created {
 		d.action.Infof("app %q does not exist yet, creating...", spec.Name)
 		app, _, err = d.apps.Create(ctx, &godo.AppCreateRequest{Spec: spec})
 		if err != nil {
 			return nil, fmt.Errorf("failed to create app: %w", err)
 		}
 	} else {
+		previous, _, err = d.apps.ListDeployments(ctx, app.GetID(), &godo.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list deployments: %w", err)
+		}
+		if err := d.handleInFlightDeployments(ctx, app.GetID(), previous); err != nil {
+			return nil, fmt.Errorf("failed to handle in-flight deployments: %w", err)
+		}
+
 		d.action.Infof("app %q already exists, updating...", spec.Name)
 		app, _, err = d.apps.Update(ctx, app.GetID(), &godo.AppUpdateRequest{Spec: spec})
 		if err != nil {
@@ -124,135 +265,182 @@ func (d *deployer) deploy(ctx context.Context, spec *godo.AppSpec) (*godo.App, e
 		}
 	}
 
-	ds, _, err := d.apps.ListDeployments(ctx, app.GetID(), &godo.ListOptions{PerPage: 1})
-	if err != nil {
-		return nil, fmt.Errorf("failed to list deployments: %w", err)
+	var dep *godo.Deployment
+	if d.inputs.forceRebuild && !created {
+		// Newly created apps are built from scratch anyway.
+		dep, err = d.forceRebuild(ctx, app.GetID())
+		if err != nil {
+			err = fmt.Errorf("failed to force rebuild: %w", err)
+		}
+	} else {
+		dep, err = d.findDeployment(ctx, app, previous)
+		if err != nil {
+			err = fmt.Errorf("failed to find deployment: %w", err)
+		}
 	}
-	if len(ds) == 0 {
-		return nil, fmt.Errorf("expected a deployment right after creating/updating the app, but got none")
+	if err != nil {
+		// The deployment caused by the update may be known even if the one to wait for isn't.
+		// Don't leave it behind if the action itself is canceled.
+		if caused := causedDeployment(app, previous); caused != nil && errors.Is(ctx.Err(), context.Canceled) {
+			d.cancelDeployment(ctx, app.GetID(), caused.GetID())
+		}
+		return nil, err
 	}
-	// The latest deployment is the deployment we just created.
-	deploymentID := ds[0].GetID()
+	deploymentID := dep.GetID()
 
 	d.action.Infof("wait for deployment to finish")
-	dep, err := d.waitForDeploymentTerminal(ctx, app.ID, deploymentID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to wait deployment to finish: %w", err)
+	streamer := d.newLogStreamer(ctx, app.ID, deploymentID)
+	final, waitErr := d.waitForDeploymentTerminal(ctx, app.ID, deploymentID, streamer.onUpdate)
+	streamer.wait()
+	if waitErr != nil && !errors.Is(waitErr, errTimeout) {
+		return nil, fmt.Errorf("failed to wait deployment to finish: %w", waitErr)
 	}
-
-	buildLogs, err := d.getLogs(ctx, app.ID, deploymentID, godo.AppLogTypeBuild)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get build logs: %w", err)
+	if final != nil {
+		dep = final
 	}
-	if len(buildLogs) > 0 {
-		d.action.SetOutput("build_logs", string(buildLogs))
-
-		if d.inputs.printBuildLogs {
-			d.action.Group("build logs")
-			d.action.Infof(string(buildLogs))
-			d.action.EndGroup()
-		}
+	if err := d.reportProgress(dep); err != nil {
+		return nil, err
 	}
 
-	deployLogs, err := d.getLogs(ctx, app.ID, deploymentID, godo.AppLogTypeDeploy)
+	// If the deployment timed out, still fetch the logs that are available to aid debugging.
+	logs, err := d.reportLogs(ctx, app.ID, deploymentID, spec)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get deploy logs: %w", err)
+		return nil, err
 	}
-	if len(deployLogs) > 0 {
-		d.action.SetOutput("deploy_logs", string(deployLogs))
 
-		if d.inputs.printDeployLogs {
-			d.action.Group("deploy logs")
-			d.action.Infof(string(deployLogs))
-			d.action.EndGroup()
+	if waitErr != nil {
+		// Fetch the app to get the latest state before returning.
+		app, _, err := d.apps.Get(ctx, app.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get app after deployment timed out: %w", err)
 		}
+		return &deployResult{app: app, deployment: dep, created: created}, fmt.Errorf("failed to wait deployment to finish: %w", waitErr)
 	}
 
 	if dep.Phase != godo.DeploymentPhase_Active {
+		d.reportFailure(dep, spec, logs)
+
 		// Fetch the app to get the latest state before returning.
 		app, _, err := d.apps.Get(ctx, app.ID)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get app after it failed: %w", err)
 		}
-		return app, fmt.Errorf("deployment failed: %s", dep.Phase)
+
+		// Superseded or canceled deployments didn't fail on their own, so rolling back would
+		// undo whatever replaced or stopped them.
+		if d.inputs.rollbackOnFailure && dep.Phase == godo.DeploymentPhase_Error {
+			d.action.SetOutput("failed_deployment_id", deploymentID)
+			restored, err := d.rollback(ctx, app, deploymentID)
+			if err != nil {
+				return &deployResult{app: app, deployment: dep, created: created}, fmt.Errorf("deployment failed: %s, and rollback failed: %w", dep.Phase, err)
+			}
+			d.action.SetOutput("restored_deployment_id", restored.GetID())
+			return &deployResult{app: app, deployment: dep, created: created}, fmt.Errorf("deployment failed: %s, rolled back to deployment %s", dep.Phase, restored.GetID())
+		}
+		return &deployResult{app: app, deployment: dep, created: created}, fmt.Errorf("deployment failed: %s", dep.Phase)
 	}
 
-	app, err = d.waitForAppLiveURL(ctx, app.ID)
+	liveApp, err := d.waitForAppLiveURL(ctx, app.ID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to wait for app to have a live URL: %w", err)
+		return &deployResult{app: liveApp, deployment: dep, created: created}, fmt.Errorf("failed to wait for app to have a live URL: %w", err)
 	}
 
-	return app, nil
-}
-
-// waitForDeploymentTerminal waits for the given deployment to be in a terminal state.
-func (d *deployer) waitForDeploymentTerminal(ctx context.Context, appID, deploymentID string) (*godo.Deployment, error) {
-	t := time.NewTicker(2 * time.Second)
-	defer t.Stop()
-
-	var dep *godo.Deployment
-	var currentPhase godo.DeploymentPhase
-	for {
-		var err error
-		dep, _, err = d.apps.GetDeployment(ctx, appID, deploymentID)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get deployment: %w", err)
+	if len(d.inputs.healthCheckPaths) > 0 {
+		if err := d.checkHealth(ctx, liveApp.GetLiveURL()); err != nil {
+			return &deployResult{app: liveApp, deployment: dep, created: created}, fmt.Errorf("failed to verify health: %w", err)
 		}
+	}
 
-		if currentPhase != dep.GetPhase() {
-			d.action.Infof("deployment is in phase: %s", dep.GetPhase())
-			currentPhase = dep.GetPhase()
+	if d.inputs.tailRunLogs > 0 {
+		if err := d.tailRunLogs(ctx, app.ID, deploymentID, spec); err != nil {
+			return &deployResult{app: liveApp, deployment: dep, created: created}, fmt.Errorf("failed to verify run logs: %w", err)
 		}
+	}
 
-		if isInTerminalPhase(dep) {
-			return dep, nil
+	if d.inputs.smokeTestCommand != "" {
+		if err := d.smokeTest(ctx, liveApp, deploymentID, previousActive); err != nil {
+			return &deployResult{app: liveApp, deployment: dep, created: created}, err
 		}
+	}
 
-		select {
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		case <-t.C:
-		}
+	return &deployResult{app: liveApp, deployment: dep, created: created}, nil
+}
+
+// errTimeout signals that waiting for App Platform timed out.
+var errTimeout = errors.New("timed out")
+
+// waitForDeploymentTerminal waits for the given deployment to be in a terminal state like
+// watchDeployment does. If the action is canceled while waiting, the deployment is canceled too,
+// so this must only be used for deployments caused by this run.
+func (d *deployer) waitForDeploymentTerminal(ctx context.Context, appID, deploymentID string, onUpdate func(*godo.Deployment)) (*godo.Deployment, error) {
+	dep, err := d.watchDeployment(ctx, appID, deploymentID, onUpdate)
+	if err != nil && errors.Is(ctx.Err(), context.Canceled) {
+		// Don't leave an orphaned deployment behind if the action itself is canceled.
+		d.cancelDeployment(ctx, appID, deploymentID)
 	}
+	return dep, err
 }
 
-// isInTerminalPhase returns whether or not the given deployment is in a terminal phase.
-func isInTerminalPhase(d *godo.Deployment) bool {
-	switch d.GetPhase() {
-	case godo.DeploymentPhase_Active, godo.DeploymentPhase_Error, godo.DeploymentPhase_Canceled, godo.DeploymentPhase_Superseded:
-		return true
+// watchDeployment waits for the given deployment to be in a terminal state, reporting phase and
+// progress step changes along the way. If set, onUpdate is called with every observed state of
+// the deployment and must not block, so the changes are reported as they happen.
+func (d *deployer) watchDeployment(ctx context.Context, appID, deploymentID string, onUpdate func(*godo.Deployment)) (*godo.Deployment, error) {
+	ctx, cancel := withTimeout(ctx, d.inputs.deploymentTimeout)
+	defer cancel()
+
+	var currentPhase godo.DeploymentPhase
+	progress := newProgressReporter(d.action)
+	dep, err := utils.WaitForDeploymentTerminal(ctx, d.apps, appID, deploymentID, func(dep *godo.Deployment) {
+		if currentPhase != dep.GetPhase() {
+			d.action.Infof("deployment is in phase: %s", dep.GetPhase())
+			currentPhase = dep.GetPhase()
+		}
+		progress.report(dep)
+		if onUpdate != nil {
+			onUpdate(dep)
+		}
+	})
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("%w after %s with deployment still in phase %s", errTimeout, d.inputs.deploymentTimeout, currentPhase)
 	}
-	return false
+	return dep, err
 }
 
-// waitForAppLiveURL waits for the given app to have a non-empty live URL.
+// waitForAppLiveURL waits for the given app to have a non-empty live URL. If it times out, the
+// latest state of the app is returned alongside the error.
 func (d *deployer) waitForAppLiveURL(ctx context.Context, appID string) (*godo.App, error) {
-	t := time.NewTicker(2 * time.Second)
-	defer t.Stop()
+	ctx, cancel := withTimeout(ctx, d.inputs.liveURLTimeout)
+	defer cancel()
 
-	var a *godo.App
-	for {
-		var err error
-		a, _, err = d.apps.Get(ctx, appID)
+	var latest *godo.App
+	if err := utils.Poll(ctx, utils.DefaultBackoff, func() (bool, error) {
+		a, _, err := d.apps.Get(ctx, appID)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get deployment: %w", err)
+			return false, fmt.Errorf("failed to get app: %w", err)
 		}
-
-		if a.GetLiveURL() != "" {
-			return a, nil
+		latest = a
+		return a.GetLiveURL() != "", nil
+	}); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return latest, fmt.Errorf("%w after %s", errTimeout, d.inputs.liveURLTimeout)
 		}
+		return nil, err
+	}
+	return latest, nil
+}
 
-		select {
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		case <-t.C:
-		}
+// withTimeout returns a context that is canceled after the given timeout. A zero timeout means
+// that the context never times out.
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return ctx, func() {}
 	}
+	return context.WithTimeout(ctx, timeout)
 }
 
-// getLogs retrieves the logs from the given historic URLs.
-func (d *deployer) getLogs(ctx context.Context, appID, deploymentID string, typ godo.AppLogType) ([]byte, error) {
-	logsResp, resp, err := d.apps.GetLogs(ctx, appID, deploymentID, "", typ, true, -1)
+// getLogs retrieves the logs of the given component from the given historic URLs.
+func (d *deployer) getLogs(ctx context.Context, appID, deploymentID, component string, typ godo.AppLogType) ([]byte, error) {
+	logsResp, resp, err := d.apps.GetLogs(ctx, appID, deploymentID, component, typ, true, -1)
 	if err != nil {
 		// Ignore if we get a 400, as this means the respective state was never reached or skipped.
 		if resp.StatusCode == http.StatusBadRequest {
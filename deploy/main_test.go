@@ -9,7 +9,9 @@ import (
 	"os"
 	"reflect"
 	"testing"
+	"time"
 
+	"github.com/digitalocean/app_actions/utils"
 	"github.com/digitalocean/godo"
 	gha "github.com/sethvargo/go-githubactions"
 	"github.com/stretchr/testify/mock"
@@ -85,13 +87,72 @@ func TestCreateSpecFromFile(t *testing.T) {
 	}
 }
 
+func TestCreateSpecFromInput(t *testing.T) {
+	jsonFilePath := t.TempDir() + "/app.json"
+	if err := os.WriteFile(jsonFilePath, []byte(`{"name":"foo","services":[{"name":"web"}]}`), 0644); err != nil {
+		t.Fatalf("failed to write spec file: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		inputs   inputs
+		expected *godo.AppSpec
+		err      bool
+	}{{
+		name:   "JSON file",
+		inputs: inputs{appSpecLocation: jsonFilePath},
+		expected: &godo.AppSpec{
+			Name:     "foo",
+			Services: []*godo.AppServiceSpec{{Name: "web"}},
+		},
+	}, {
+		name:   "inline YAML",
+		inputs: inputs{appSpec: "name: foo\nservices:\n- name: ${SERVICE_NAME}\n", appSpecLocation: jsonFilePath},
+		expected: &godo.AppSpec{
+			Name:     "foo",
+			Services: []*godo.AppServiceSpec{{Name: "api"}},
+		},
+	}, {
+		name:   "inline JSON with name override",
+		inputs: inputs{appSpec: `{"name":"foo","domains":[{"domain":"example.com"}]}`, appNameOverride: "bar"},
+		expected: &godo.AppSpec{
+			Name:    "bar",
+			Domains: []*godo.AppDomainSpec{{Domain: "example.com"}},
+		},
+	}, {
+		name:   "invalid inline spec",
+		inputs: inputs{appSpec: "name: ["},
+		err:    true,
+	}, {
+		name:   "empty spec",
+		inputs: inputs{appSpec: "# nothing"},
+		err:    true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("SERVICE_NAME", "api")
+			d := &deployer{inputs: test.inputs}
+
+			spec, err := d.createSpec(context.Background())
+			if test.err {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, test.expected, spec)
+		})
+	}
+}
+
 func TestCreateSpecFromExistingApp(t *testing.T) {
 	tests := []struct {
-		name       string
-		appService *mockedAppsService
-		envs       map[string]string
-		expected   *godo.AppSpec
-		err        bool
+		name         string
+		appService   *mockedAppsService
+		envs         map[string]string
+		nameOverride string
+		expected     *godo.AppSpec
+		err          bool
 	}{{
 		name: "existing app",
 		appService: func() *mockedAppsService {
@@ -125,6 +186,24 @@ func TestCreateSpecFromExistingApp(t *testing.T) {
 				},
 			}},
 		},
+	}, {
+		name: "copy of existing app",
+		appService: func() *mockedAppsService {
+			as := &mockedAppsService{}
+			as.On("List", mock.Anything, mock.Anything).Return([]*godo.App{{
+				Spec: &godo.AppSpec{
+					Name:     "foo",
+					Domains:  []*godo.AppDomainSpec{{Domain: "example.com"}},
+					Services: []*godo.AppServiceSpec{{Name: "web"}},
+				},
+			}}, &godo.Response{}, nil)
+			return as
+		}(),
+		nameOverride: "foo-copy",
+		expected: &godo.AppSpec{
+			Name:     "foo-copy",
+			Services: []*godo.AppServiceSpec{{Name: "web"}},
+		},
 	}, {
 		name: "no app",
 		appService: func() *mockedAppsService {
@@ -147,7 +226,7 @@ func TestCreateSpecFromExistingApp(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			d := &deployer{
 				apps:   test.appService,
-				inputs: inputs{appName: "foo"},
+				inputs: inputs{appName: "foo", appNameOverride: test.nameOverride},
 			}
 
 			for k, v := range test.envs {
@@ -171,12 +250,14 @@ func TestDeploy(t *testing.T) {
 	appID := "app-id"
 	deploymentID := "deployment-id"
 	spec := &godo.AppSpec{
-		Name: "foo",
+		Name:     "foo",
+		Services: []*godo.AppServiceSpec{{Name: "web"}},
 	}
 
 	tests := []struct {
 		name           string
 		appService     *mockedAppsService
+		rollbacks      *mockedRollbackService
 		logsRT         *mockedRoundtripper
 		inputs         inputs
 		expectedLogs   []byte
@@ -187,6 +268,7 @@ func TestDeploy(t *testing.T) {
 		appService: func() *mockedAppsService {
 			as := &mockedAppsService{}
 			as.On("List", ctx, mock.Anything).Return([]*godo.App{}, &godo.Response{}, nil)
+			as.On("Propose", ctx, &godo.AppProposeRequest{Spec: spec}).Return(&godo.AppProposeResponse{AppCost: 5}, &godo.Response{}, nil)
 			as.On("Create", ctx, mock.Anything).Return(&godo.App{ID: appID}, &godo.Response{}, nil)
 			as.On("ListDeployments", ctx, appID, mock.Anything).Return([]*godo.Deployment{{
 				ID: deploymentID,
@@ -194,10 +276,10 @@ func TestDeploy(t *testing.T) {
 			as.On("GetDeployment", ctx, appID, deploymentID).Return(&godo.Deployment{
 				Phase: godo.DeploymentPhase_Active,
 			}, &godo.Response{}, nil)
-			as.On("GetLogs", ctx, appID, deploymentID, "", godo.AppLogTypeBuild, true, -1).Return(&godo.AppLogs{
+			as.On("GetLogs", ctx, appID, deploymentID, "web", godo.AppLogTypeBuild, true, -1).Return(&godo.AppLogs{
 				HistoricURLs: []string{"http://build.com"},
 			}, &godo.Response{}, nil)
-			as.On("GetLogs", ctx, appID, deploymentID, "", godo.AppLogTypeDeploy, true, -1).Return(&godo.AppLogs{
+			as.On("GetLogs", ctx, appID, deploymentID, "web", godo.AppLogTypeDeploy, true, -1).Return(&godo.AppLogs{
 				HistoricURLs: []string{"http://deploy.com"},
 			}, &godo.Response{}, nil)
 			as.On("Get", ctx, appID).Return(&godo.App{ID: appID, LiveURL: "https://example.com"}, &godo.Response{}, nil)
@@ -217,13 +299,14 @@ func TestDeploy(t *testing.T) {
 			printBuildLogs:  true,
 			printDeployLogs: true,
 		},
-		expectedLogs: []byte(`app "foo" does not exist yet, creating...
+		expectedLogs: []byte(`app spec is valid, estimated monthly cost: $5.00
+app "foo" does not exist yet, creating...
 wait for deployment to finish
 deployment is in phase: ACTIVE
-::group::build logs
+::group::build logs (web)
 build log
 ::endgroup::
-::group::deploy logs
+::group::deploy logs (web)
 deploy log
 ::endgroup::
 `),
@@ -233,12 +316,17 @@ _GitHubActionsFileCommandDelimeter_
 deploy_logs<<_GitHubActionsFileCommandDelimeter_
 deploy log
 _GitHubActionsFileCommandDelimeter_
+component_logs<<_GitHubActionsFileCommandDelimeter_
+{"web":{"build_logs":"build log","deploy_logs":"deploy log"}}
+_GitHubActionsFileCommandDelimeter_
 `),
 	}, {
 		name: "success on preexisting app",
 		appService: func() *mockedAppsService {
 			as := &mockedAppsService{}
 			as.On("List", ctx, mock.Anything).Return([]*godo.App{{ID: appID, Spec: spec}}, &godo.Response{}, nil)
+			as.On("Propose", ctx, &godo.AppProposeRequest{Spec: spec, AppID: appID}).Return(&godo.AppProposeResponse{AppCost: 5}, &godo.Response{}, nil)
+			as.On("ListDeployments", ctx, appID, mock.Anything).Return([]*godo.Deployment{}, &godo.Response{}, nil).Once()
 			as.On("Update", ctx, appID, mock.Anything).Return(&godo.App{ID: appID}, &godo.Response{}, nil)
 			as.On("ListDeployments", ctx, appID, mock.Anything).Return([]*godo.Deployment{{
 				ID: deploymentID,
@@ -246,10 +334,10 @@ _GitHubActionsFileCommandDelimeter_
 			as.On("GetDeployment", ctx, appID, deploymentID).Return(&godo.Deployment{
 				Phase: godo.DeploymentPhase_Active,
 			}, &godo.Response{}, nil)
-			as.On("GetLogs", ctx, appID, deploymentID, "", godo.AppLogTypeBuild, true, -1).Return(&godo.AppLogs{
+			as.On("GetLogs", ctx, appID, deploymentID, "web", godo.AppLogTypeBuild, true, -1).Return(&godo.AppLogs{
 				HistoricURLs: []string{"http://build.com"},
 			}, &godo.Response{}, nil)
-			as.On("GetLogs", ctx, appID, deploymentID, "", godo.AppLogTypeDeploy, true, -1).Return(&godo.AppLogs{
+			as.On("GetLogs", ctx, appID, deploymentID, "web", godo.AppLogTypeDeploy, true, -1).Return(&godo.AppLogs{
 				HistoricURLs: []string{"http://deploy.com"},
 			}, &godo.Response{}, nil)
 			as.On("Get", ctx, appID).Return(&godo.App{ID: appID, LiveURL: "https://example.com"}, &godo.Response{}, nil)
@@ -265,22 +353,34 @@ _GitHubActionsFileCommandDelimeter_
 			}, nil).Once()
 			return rt
 		}(),
-		expectedLogs: []byte(`app "foo" already exists, updating...
+		expectedLogs: []byte(`app spec is valid, estimated monthly cost: $5.00
+::group::spec diff
+no changes
+::endgroup::
+app "foo" already exists, updating...
 wait for deployment to finish
 deployment is in phase: ACTIVE
 `),
-		expectedOutput: []byte(`build_logs<<_GitHubActionsFileCommandDelimeter_
+		expectedOutput: []byte(`spec_diff<<_GitHubActionsFileCommandDelimeter_
+{}
+_GitHubActionsFileCommandDelimeter_
+build_logs<<_GitHubActionsFileCommandDelimeter_
 build log
 _GitHubActionsFileCommandDelimeter_
 deploy_logs<<_GitHubActionsFileCommandDelimeter_
 deploy log
 _GitHubActionsFileCommandDelimeter_
+component_logs<<_GitHubActionsFileCommandDelimeter_
+{"web":{"build_logs":"build log","deploy_logs":"deploy log"}}
+_GitHubActionsFileCommandDelimeter_
 `),
 	}, {
 		name: "fails to deploy",
 		appService: func() *mockedAppsService {
 			as := &mockedAppsService{}
 			as.On("List", ctx, mock.Anything).Return([]*godo.App{{ID: appID, Spec: spec}}, &godo.Response{}, nil)
+			as.On("Propose", ctx, &godo.AppProposeRequest{Spec: spec, AppID: appID}).Return(&godo.AppProposeResponse{AppCost: 5}, &godo.Response{}, nil)
+			as.On("ListDeployments", ctx, appID, mock.Anything).Return([]*godo.Deployment{}, &godo.Response{}, nil).Once()
 			as.On("Update", ctx, appID, mock.Anything).Return(&godo.App{ID: appID}, &godo.Response{}, nil)
 			as.On("ListDeployments", ctx, appID, mock.Anything).Return([]*godo.Deployment{{
 				ID: deploymentID,
@@ -288,10 +388,10 @@ _GitHubActionsFileCommandDelimeter_
 			as.On("GetDeployment", ctx, appID, deploymentID).Return(&godo.Deployment{
 				Phase: godo.DeploymentPhase_Error,
 			}, &godo.Response{}, nil)
-			as.On("GetLogs", ctx, appID, deploymentID, "", godo.AppLogTypeBuild, true, -1).Return(&godo.AppLogs{
+			as.On("GetLogs", ctx, appID, deploymentID, "web", godo.AppLogTypeBuild, true, -1).Return(&godo.AppLogs{
 				HistoricURLs: []string{"http://build.com"},
 			}, &godo.Response{}, nil)
-			as.On("GetLogs", ctx, appID, deploymentID, "", godo.AppLogTypeDeploy, true, -1).Return(&godo.AppLogs{
+			as.On("GetLogs", ctx, appID, deploymentID, "web", godo.AppLogTypeDeploy, true, -1).Return(&godo.AppLogs{
 				HistoricURLs: []string{"http://deploy.com"},
 			}, &godo.Response{}, nil)
 			as.On("Get", ctx, appID).Return(&godo.App{ID: appID}, &godo.Response{}, nil)
@@ -308,16 +408,116 @@ _GitHubActionsFileCommandDelimeter_
 			return rt
 		}(),
 		err: true,
-		expectedLogs: []byte(`app "foo" already exists, updating...
+		expectedLogs: []byte(`app spec is valid, estimated monthly cost: $5.00
+::group::spec diff
+no changes
+::endgroup::
+app "foo" already exists, updating...
 wait for deployment to finish
 deployment is in phase: ERROR
 `),
-		expectedOutput: []byte(`build_logs<<_GitHubActionsFileCommandDelimeter_
+		expectedOutput: []byte(`spec_diff<<_GitHubActionsFileCommandDelimeter_
+{}
+_GitHubActionsFileCommandDelimeter_
+build_logs<<_GitHubActionsFileCommandDelimeter_
 build log
 _GitHubActionsFileCommandDelimeter_
 deploy_logs<<_GitHubActionsFileCommandDelimeter_
 deploy log
 _GitHubActionsFileCommandDelimeter_
+component_logs<<_GitHubActionsFileCommandDelimeter_
+{"web":{"build_logs":"build log","deploy_logs":"deploy log"}}
+_GitHubActionsFileCommandDelimeter_
+`),
+	}, {
+		name: "rolls back on failure",
+		appService: func() *mockedAppsService {
+			as := &mockedAppsService{}
+			as.On("List", ctx, mock.Anything).Return([]*godo.App{{ID: appID, Spec: spec}}, &godo.Response{}, nil)
+			as.On("Propose", ctx, &godo.AppProposeRequest{Spec: spec, AppID: appID}).Return(&godo.AppProposeResponse{AppCost: 5}, &godo.Response{}, nil)
+			as.On("ListDeployments", ctx, appID, mock.Anything).Return([]*godo.Deployment{}, &godo.Response{}, nil).Once()
+			as.On("Update", ctx, appID, mock.Anything).Return(&godo.App{ID: appID}, &godo.Response{}, nil)
+			as.On("ListDeployments", ctx, appID, mock.Anything).Return([]*godo.Deployment{{
+				ID: deploymentID,
+			}}, &godo.Response{}, nil)
+			as.On("GetDeployment", ctx, appID, deploymentID).Return(&godo.Deployment{
+				Phase: godo.DeploymentPhase_Error,
+			}, &godo.Response{}, nil)
+			as.On("GetDeployment", ctx, appID, "rollback-id").Return(&godo.Deployment{
+				Phase: godo.DeploymentPhase_Active,
+			}, &godo.Response{}, nil)
+			as.On("GetLogs", ctx, appID, deploymentID, "web", godo.AppLogTypeBuild, true, -1).Return(&godo.AppLogs{}, &godo.Response{Response: &http.Response{StatusCode: http.StatusBadRequest}}, errors.New("an error"))
+			as.On("GetLogs", ctx, appID, deploymentID, "web", godo.AppLogTypeDeploy, true, -1).Return(&godo.AppLogs{}, &godo.Response{Response: &http.Response{StatusCode: http.StatusBadRequest}}, errors.New("an error"))
+			as.On("Get", ctx, appID).Return(&godo.App{ID: appID, ActiveDeployment: &godo.Deployment{ID: "previous-id"}}, &godo.Response{}, nil)
+			return as
+		}(),
+		rollbacks: func() *mockedRollbackService {
+			rs := &mockedRollbackService{}
+			req := &utils.RollbackRequest{DeploymentID: "previous-id", SkipPin: true}
+			rs.On("ValidateRollback", ctx, appID, req).Return(&utils.RollbackValidation{Valid: true}, &godo.Response{}, nil)
+			rs.On("Rollback", ctx, appID, req).Return(&godo.Deployment{ID: "rollback-id"}, &godo.Response{}, nil)
+			return rs
+		}(),
+		inputs: inputs{
+			rollbackOnFailure: true,
+		},
+		err: true,
+		expectedLogs: []byte(`app spec is valid, estimated monthly cost: $5.00
+::group::spec diff
+no changes
+::endgroup::
+app "foo" already exists, updating...
+wait for deployment to finish
+deployment is in phase: ERROR
+rolling back to deployment previous-id
+wait for rollback to finish
+deployment is in phase: ACTIVE
+`),
+		expectedOutput: []byte(`spec_diff<<_GitHubActionsFileCommandDelimeter_
+{}
+_GitHubActionsFileCommandDelimeter_
+failed_deployment_id<<_GitHubActionsFileCommandDelimeter_
+deployment-id
+_GitHubActionsFileCommandDelimeter_
+restored_deployment_id<<_GitHubActionsFileCommandDelimeter_
+previous-id
+_GitHubActionsFileCommandDelimeter_
+`),
+	}, {
+		name: "doesn't roll back superseded deployments",
+		appService: func() *mockedAppsService {
+			as := &mockedAppsService{}
+			as.On("List", ctx, mock.Anything).Return([]*godo.App{{ID: appID, Spec: spec}}, &godo.Response{}, nil)
+			as.On("Propose", ctx, &godo.AppProposeRequest{Spec: spec, AppID: appID}).Return(&godo.AppProposeResponse{AppCost: 5}, &godo.Response{}, nil)
+			as.On("ListDeployments", ctx, appID, mock.Anything).Return([]*godo.Deployment{}, &godo.Response{}, nil).Once()
+			as.On("Update", ctx, appID, mock.Anything).Return(&godo.App{ID: appID}, &godo.Response{}, nil)
+			as.On("ListDeployments", ctx, appID, mock.Anything).Return([]*godo.Deployment{{
+				ID: deploymentID,
+			}}, &godo.Response{}, nil)
+			as.On("GetDeployment", ctx, appID, deploymentID).Return(&godo.Deployment{
+				Phase: godo.DeploymentPhase_Superseded,
+			}, &godo.Response{}, nil)
+			as.On("GetLogs", ctx, appID, deploymentID, "web", godo.AppLogTypeBuild, true, -1).Return(&godo.AppLogs{}, &godo.Response{Response: &http.Response{StatusCode: http.StatusBadRequest}}, errors.New("an error"))
+			as.On("GetLogs", ctx, appID, deploymentID, "web", godo.AppLogTypeDeploy, true, -1).Return(&godo.AppLogs{}, &godo.Response{Response: &http.Response{StatusCode: http.StatusBadRequest}}, errors.New("an error"))
+			as.On("Get", ctx, appID).Return(&godo.App{ID: appID, ActiveDeployment: &godo.Deployment{ID: "previous-id"}}, &godo.Response{}, nil)
+			return as
+		}(),
+		rollbacks: &mockedRollbackService{},
+		inputs: inputs{
+			rollbackOnFailure: true,
+		},
+		err: true,
+		expectedLogs: []byte(`app spec is valid, estimated monthly cost: $5.00
+::group::spec diff
+no changes
+::endgroup::
+app "foo" already exists, updating...
+wait for deployment to finish
+deployment is in phase: SUPERSEDED
+`),
+		expectedOutput: []byte(`spec_diff<<_GitHubActionsFileCommandDelimeter_
+{}
+_GitHubActionsFileCommandDelimeter_
 `),
 	}, {
 		name: "fails to list apps",
@@ -327,46 +527,64 @@ _GitHubActionsFileCommandDelimeter_
 			return as
 		}(),
 		err: true,
+	}, {
+		name: "fails to validate spec",
+		appService: func() *mockedAppsService {
+			as := &mockedAppsService{}
+			as.On("List", ctx, mock.Anything).Return([]*godo.App{{ID: appID, Spec: spec}}, &godo.Response{}, nil)
+			as.On("Propose", ctx, &godo.AppProposeRequest{Spec: spec, AppID: appID}).Return(&godo.AppProposeResponse{}, &godo.Response{}, errors.New("an error"))
+			return as
+		}(),
+		err: true,
 	}, {
 		name: "fails to create app",
 		appService: func() *mockedAppsService {
 			as := &mockedAppsService{}
 			as.On("List", ctx, mock.Anything).Return([]*godo.App{}, &godo.Response{}, nil)
+			as.On("Propose", ctx, &godo.AppProposeRequest{Spec: spec}).Return(&godo.AppProposeResponse{AppCost: 5}, &godo.Response{}, nil)
 			as.On("Create", ctx, mock.Anything).Return(&godo.App{ID: appID}, &godo.Response{}, errors.New("an error"))
 			return as
 		}(),
 		err: true,
-		expectedLogs: []byte(`app "foo" does not exist yet, creating...
+		expectedLogs: []byte(`app spec is valid, estimated monthly cost: $5.00
+app "foo" does not exist yet, creating...
 `),
 	}, {
 		name: "fails to list deployments",
 		appService: func() *mockedAppsService {
 			as := &mockedAppsService{}
 			as.On("List", ctx, mock.Anything).Return([]*godo.App{}, &godo.Response{}, nil)
+			as.On("Propose", ctx, &godo.AppProposeRequest{Spec: spec}).Return(&godo.AppProposeResponse{AppCost: 5}, &godo.Response{}, nil)
 			as.On("Create", ctx, mock.Anything).Return(&godo.App{ID: appID}, &godo.Response{}, nil)
 			as.On("ListDeployments", ctx, appID, mock.Anything).Return([]*godo.Deployment{}, &godo.Response{}, errors.New("an error"))
 			return as
 		}(),
 		err: true,
-		expectedLogs: []byte(`app "foo" does not exist yet, creating...
+		expectedLogs: []byte(`app spec is valid, estimated monthly cost: $5.00
+app "foo" does not exist yet, creating...
 `),
 	}, {
-		name: "returns an empty deployment list",
+		name: "fails to create deployment if none was created",
 		appService: func() *mockedAppsService {
 			as := &mockedAppsService{}
 			as.On("List", ctx, mock.Anything).Return([]*godo.App{}, &godo.Response{}, nil)
+			as.On("Propose", ctx, &godo.AppProposeRequest{Spec: spec}).Return(&godo.AppProposeResponse{AppCost: 5}, &godo.Response{}, nil)
 			as.On("Create", ctx, mock.Anything).Return(&godo.App{ID: appID}, &godo.Response{}, nil)
 			as.On("ListDeployments", ctx, appID, mock.Anything).Return([]*godo.Deployment{}, &godo.Response{}, nil)
+			as.On("CreateDeployment", ctx, appID, mock.Anything).Return(&godo.Deployment{}, &godo.Response{}, errors.New("an error"))
 			return as
 		}(),
 		err: true,
-		expectedLogs: []byte(`app "foo" does not exist yet, creating...
+		expectedLogs: []byte(`app spec is valid, estimated monthly cost: $5.00
+app "foo" does not exist yet, creating...
+no deployment was created, creating one...
 `),
 	}, {
 		name: "fails to get deployment for phase poll",
 		appService: func() *mockedAppsService {
 			as := &mockedAppsService{}
 			as.On("List", ctx, mock.Anything).Return([]*godo.App{}, &godo.Response{}, nil)
+			as.On("Propose", ctx, &godo.AppProposeRequest{Spec: spec}).Return(&godo.AppProposeResponse{AppCost: 5}, &godo.Response{}, nil)
 			as.On("Create", ctx, mock.Anything).Return(&godo.App{ID: appID}, &godo.Response{}, nil)
 			as.On("ListDeployments", ctx, appID, mock.Anything).Return([]*godo.Deployment{{
 				ID: deploymentID,
@@ -375,7 +593,8 @@ _GitHubActionsFileCommandDelimeter_
 			return as
 		}(),
 		err: true,
-		expectedLogs: []byte(`app "foo" does not exist yet, creating...
+		expectedLogs: []byte(`app spec is valid, estimated monthly cost: $5.00
+app "foo" does not exist yet, creating...
 wait for deployment to finish
 `),
 	}, {
@@ -383,6 +602,7 @@ wait for deployment to finish
 		appService: func() *mockedAppsService {
 			as := &mockedAppsService{}
 			as.On("List", ctx, mock.Anything).Return([]*godo.App{}, &godo.Response{}, nil)
+			as.On("Propose", ctx, &godo.AppProposeRequest{Spec: spec}).Return(&godo.AppProposeResponse{AppCost: 5}, &godo.Response{}, nil)
 			as.On("Create", ctx, mock.Anything).Return(&godo.App{ID: appID}, &godo.Response{}, nil)
 			as.On("ListDeployments", ctx, appID, mock.Anything).Return([]*godo.Deployment{{
 				ID: deploymentID,
@@ -390,13 +610,14 @@ wait for deployment to finish
 			as.On("GetDeployment", ctx, appID, deploymentID).Return(&godo.Deployment{
 				Phase: godo.DeploymentPhase_Active,
 			}, &godo.Response{}, nil)
-			as.On("GetLogs", ctx, appID, deploymentID, "", godo.AppLogTypeBuild, true, -1).Return(&godo.AppLogs{
+			as.On("GetLogs", ctx, appID, deploymentID, "web", godo.AppLogTypeBuild, true, -1).Return(&godo.AppLogs{
 				HistoricURLs: []string{"http://build.com"},
 			}, &godo.Response{Response: &http.Response{StatusCode: http.StatusBadGateway}}, errors.New("an error"))
 			return as
 		}(),
 		err: true,
-		expectedLogs: []byte(`app "foo" does not exist yet, creating...
+		expectedLogs: []byte(`app spec is valid, estimated monthly cost: $5.00
+app "foo" does not exist yet, creating...
 wait for deployment to finish
 deployment is in phase: ACTIVE
 `),
@@ -405,6 +626,7 @@ deployment is in phase: ACTIVE
 		appService: func() *mockedAppsService {
 			as := &mockedAppsService{}
 			as.On("List", ctx, mock.Anything).Return([]*godo.App{}, &godo.Response{}, nil)
+			as.On("Propose", ctx, &godo.AppProposeRequest{Spec: spec}).Return(&godo.AppProposeResponse{AppCost: 5}, &godo.Response{}, nil)
 			as.On("Create", ctx, mock.Anything).Return(&godo.App{ID: appID}, &godo.Response{}, nil)
 			as.On("ListDeployments", ctx, appID, mock.Anything).Return([]*godo.Deployment{{
 				ID: deploymentID,
@@ -412,16 +634,17 @@ deployment is in phase: ACTIVE
 			as.On("GetDeployment", ctx, appID, deploymentID).Return(&godo.Deployment{
 				Phase: godo.DeploymentPhase_Active,
 			}, &godo.Response{}, nil)
-			as.On("GetLogs", ctx, appID, deploymentID, "", godo.AppLogTypeBuild, true, -1).Return(&godo.AppLogs{
+			as.On("GetLogs", ctx, appID, deploymentID, "web", godo.AppLogTypeBuild, true, -1).Return(&godo.AppLogs{
 				HistoricURLs: []string{"http://build.com"},
 			}, &godo.Response{Response: &http.Response{StatusCode: http.StatusBadRequest}}, errors.New("an error"))
-			as.On("GetLogs", ctx, appID, deploymentID, "", godo.AppLogTypeDeploy, true, -1).Return(&godo.AppLogs{
+			as.On("GetLogs", ctx, appID, deploymentID, "web", godo.AppLogTypeDeploy, true, -1).Return(&godo.AppLogs{
 				HistoricURLs: []string{"http://deploy.com"},
 			}, &godo.Response{Response: &http.Response{StatusCode: http.StatusBadRequest}}, errors.New("an error"))
 			as.On("Get", ctx, appID).Return(&godo.App{ID: appID, LiveURL: "https://example.com"}, &godo.Response{}, nil)
 			return as
 		}(),
-		expectedLogs: []byte(`app "foo" does not exist yet, creating...
+		expectedLogs: []byte(`app spec is valid, estimated monthly cost: $5.00
+app "foo" does not exist yet, creating...
 wait for deployment to finish
 deployment is in phase: ACTIVE
 `),
@@ -430,6 +653,7 @@ deployment is in phase: ACTIVE
 		appService: func() *mockedAppsService {
 			as := &mockedAppsService{}
 			as.On("List", ctx, mock.Anything).Return([]*godo.App{}, &godo.Response{}, nil)
+			as.On("Propose", ctx, &godo.AppProposeRequest{Spec: spec}).Return(&godo.AppProposeResponse{AppCost: 5}, &godo.Response{}, nil)
 			as.On("Create", ctx, mock.Anything).Return(&godo.App{ID: appID}, &godo.Response{}, nil)
 			as.On("ListDeployments", ctx, appID, mock.Anything).Return([]*godo.Deployment{{
 				ID: deploymentID,
@@ -437,17 +661,18 @@ deployment is in phase: ACTIVE
 			as.On("GetDeployment", ctx, appID, deploymentID).Return(&godo.Deployment{
 				Phase: godo.DeploymentPhase_Active,
 			}, &godo.Response{}, nil)
-			as.On("GetLogs", ctx, appID, deploymentID, "", godo.AppLogTypeBuild, true, -1).Return(&godo.AppLogs{
+			as.On("GetLogs", ctx, appID, deploymentID, "web", godo.AppLogTypeBuild, true, -1).Return(&godo.AppLogs{
 				HistoricURLs: []string{"http://build.com"},
 			}, &godo.Response{Response: &http.Response{StatusCode: http.StatusBadRequest}}, errors.New("an error"))
-			as.On("GetLogs", ctx, appID, deploymentID, "", godo.AppLogTypeDeploy, true, -1).Return(&godo.AppLogs{
+			as.On("GetLogs", ctx, appID, deploymentID, "web", godo.AppLogTypeDeploy, true, -1).Return(&godo.AppLogs{
 				HistoricURLs: []string{"http://deploy.com"},
 			}, &godo.Response{Response: &http.Response{StatusCode: http.StatusBadRequest}}, errors.New("an error"))
 			as.On("Get", ctx, appID).Return(&godo.App{ID: appID, LiveURL: "https://example.com"}, &godo.Response{}, errors.New("an error"))
 			return as
 		}(),
 		err: true,
-		expectedLogs: []byte(`app "foo" does not exist yet, creating...
+		expectedLogs: []byte(`app spec is valid, estimated monthly cost: $5.00
+app "foo" does not exist yet, creating...
 wait for deployment to finish
 deployment is in phase: ACTIVE
 `),
@@ -467,6 +692,7 @@ deployment is in phase: ACTIVE
 					}
 				})),
 				apps:       test.appService,
+				rollbacks:  test.rollbacks,
 				httpClient: &http.Client{Transport: test.logsRT},
 				inputs:     test.inputs,
 			}
@@ -493,6 +719,77 @@ deployment is in phase: ACTIVE
 	}
 }
 
+func TestDeployTimeout(t *testing.T) {
+	ctx := context.Background()
+	appID := "app-id"
+	deploymentID := "deployment-id"
+	spec := &godo.AppSpec{Name: "foo"}
+
+	tests := []struct {
+		name        string
+		appService  *mockedAppsService
+		inputs      inputs
+		expectedErr string
+	}{{
+		name: "deployment times out",
+		appService: func() *mockedAppsService {
+			as := &mockedAppsService{}
+			as.On("List", ctx, mock.Anything).Return([]*godo.App{}, &godo.Response{}, nil)
+			as.On("Propose", ctx, mock.Anything).Return(&godo.AppProposeResponse{}, &godo.Response{}, nil)
+			as.On("Create", ctx, mock.Anything).Return(&godo.App{ID: appID}, &godo.Response{}, nil)
+			as.On("ListDeployments", ctx, appID, mock.Anything).Return([]*godo.Deployment{{
+				ID: deploymentID,
+			}}, &godo.Response{}, nil)
+			as.On("GetDeployment", mock.Anything, appID, deploymentID).Return(&godo.Deployment{
+				Phase: godo.DeploymentPhase_PendingBuild,
+			}, &godo.Response{}, nil)
+			as.On("GetLogs", ctx, appID, deploymentID, "", mock.Anything, true, -1).Return(&godo.AppLogs{}, &godo.Response{Response: &http.Response{StatusCode: http.StatusBadRequest}}, errors.New("an error"))
+			as.On("Get", ctx, appID).Return(&godo.App{ID: appID}, &godo.Response{}, nil)
+			return as
+		}(),
+		inputs:      inputs{deploymentTimeout: 10 * time.Millisecond},
+		expectedErr: "failed to wait deployment to finish: timed out after 10ms with deployment still in phase PENDING_BUILD",
+	}, {
+		name: "live URL times out",
+		appService: func() *mockedAppsService {
+			as := &mockedAppsService{}
+			as.On("List", ctx, mock.Anything).Return([]*godo.App{}, &godo.Response{}, nil)
+			as.On("Propose", ctx, mock.Anything).Return(&godo.AppProposeResponse{}, &godo.Response{}, nil)
+			as.On("Create", ctx, mock.Anything).Return(&godo.App{ID: appID}, &godo.Response{}, nil)
+			as.On("ListDeployments", ctx, appID, mock.Anything).Return([]*godo.Deployment{{
+				ID: deploymentID,
+			}}, &godo.Response{}, nil)
+			as.On("GetDeployment", ctx, appID, deploymentID).Return(&godo.Deployment{
+				ID:    deploymentID,
+				Phase: godo.DeploymentPhase_Active,
+			}, &godo.Response{}, nil)
+			as.On("GetLogs", ctx, appID, deploymentID, "", mock.Anything, true, -1).Return(&godo.AppLogs{}, &godo.Response{Response: &http.Response{StatusCode: http.StatusBadRequest}}, errors.New("an error"))
+			as.On("Get", mock.Anything, appID).Return(&godo.App{ID: appID}, &godo.Response{}, nil)
+			return as
+		}(),
+		inputs:      inputs{liveURLTimeout: 10 * time.Millisecond},
+		expectedErr: "failed to wait for app to have a live URL: timed out after 10ms",
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d := &deployer{
+				action: gha.New(gha.WithWriter(io.Discard), gha.WithGetenv(func(string) string { return "" })),
+				apps:   test.appService,
+				inputs: test.inputs,
+			}
+
+			res, err := d.deploy(ctx, spec)
+			require.EqualError(t, err, test.expectedErr)
+			require.ErrorIs(t, err, errTimeout)
+			// The app and deployment are still returned to surface them as outputs.
+			require.Equal(t, appID, res.app.GetID())
+			require.Equal(t, deploymentID, res.deployment.GetID())
+			require.True(t, res.created)
+		})
+	}
+}
+
 type mockedRoundtripper struct {
 	mock.Mock
 }
@@ -527,6 +824,11 @@ func (m *mockedAppsService) List(ctx context.Context, opt *godo.ListOptions) ([]
 	return args.Get(0).([]*godo.App), args.Get(1).(*godo.Response), args.Error(2)
 }
 
+func (m *mockedAppsService) Propose(ctx context.Context, req *godo.AppProposeRequest) (*godo.AppProposeResponse, *godo.Response, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(*godo.AppProposeResponse), args.Get(1).(*godo.Response), args.Error(2)
+}
+
 func (m *mockedAppsService) GetDeployment(ctx context.Context, appID string, deploymentID string) (*godo.Deployment, *godo.Response, error) {
 	args := m.Called(ctx, appID, deploymentID)
 	return args.Get(0).(*godo.Deployment), args.Get(1).(*godo.Response), args.Error(2)
@@ -541,3 +843,8 @@ func (m *mockedAppsService) GetLogs(ctx context.Context, appID, deploymentID, co
 	args := m.Called(ctx, appID, deploymentID, component, logType, follow, tailLines)
 	return args.Get(0).(*godo.AppLogs), args.Get(1).(*godo.Response), args.Error(2)
 }
+
+func (m *mockedAppsService) CreateDeployment(ctx context.Context, appID string, req ...*godo.DeploymentCreateRequest) (*godo.Deployment, *godo.Response, error) {
+	args := m.Called(ctx, appID, req)
+	return args.Get(0).(*godo.Deployment), args.Get(1).(*godo.Response), args.Error(2)
+}
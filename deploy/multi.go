@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/digitalocean/godo"
+	gha "github.com/sethvargo/go-githubactions"
+)
+
+// resolveSpecLocations expands the given app spec locations, which may be glob patterns, into the
+// paths of the individual app specs. Locations that aren't patterns are returned as-is.
+func resolveSpecLocations(locations []string) ([]string, error) {
+	var res []string
+	for _, location := range locations {
+		matches, err := filepath.Glob(location)
+		if err != nil {
+			return nil, fmt.Errorf("invalid app spec location %q: %w", location, err)
+		}
+		if len(matches) == 0 {
+			if strings.ContainsAny(location, "*?[") {
+				return nil, fmt.Errorf("no app spec matches %q", location)
+			}
+			// Let reading the spec fail with a descriptive error if the file doesn't exist.
+			matches = []string{location}
+		}
+		for _, match := range matches {
+			if !slices.Contains(res, match) {
+				res = append(res, match)
+			}
+		}
+	}
+	if len(res) == 0 {
+		return nil, fmt.Errorf("no app spec location given")
+	}
+	return res, nil
+}
+
+// appOutput is the outcome of deploying one of multiple apps.
+type appOutput struct {
+	SpecLocation    string `json:"spec_location"`
+	AppID           string `json:"app_id,omitempty"`
+	LiveURL         string `json:"live_url,omitempty"`
+	DeploymentID    string `json:"deployment_id,omitempty"`
+	DeploymentPhase string `json:"deployment_phase,omitempty"`
+	Error           string `json:"error,omitempty"`
+}
+
+// multiApp is one of multiple apps being deployed.
+type multiApp struct {
+	location string
+	d        *deployer
+	logs     bytes.Buffer
+	spec     *godo.AppSpec
+	// err is the error creating the app's spec, if any.
+	err error
+}
+
+// deployApps deploys the app specs at the given locations concurrently, with at most the
+// configured parallelism at once. The specs are created upfront to fail before deploying anything
+// if multiple specs define the same app. The logs of each app are printed in a group once it
+// finished and the outcome of all apps is surfaced as a JSON output keyed by app name.
+func (d *deployer) deployApps(ctx context.Context, locations []string) error {
+	if d.inputs.deployPRPreview {
+		return fmt.Errorf("deploy_pr_preview is not supported with multiple app specs")
+	}
+
+	apps := make([]*multiApp, 0, len(locations))
+	locationsByName := make(map[string]string, len(locations))
+	for _, location := range locations {
+		app := &multiApp{location: location}
+		app.d = d.forApp(location, &syncWriter{w: &app.logs})
+		app.spec, app.err = app.d.createSpec(ctx)
+		if app.err != nil {
+			app.err = fmt.Errorf("failed to create spec: %w", app.err)
+		} else if other, ok := locationsByName[app.spec.GetName()]; ok {
+			return fmt.Errorf("app %q is defined by both %q and %q", app.spec.GetName(), other, location)
+		} else {
+			locationsByName[app.spec.GetName()] = location
+		}
+		apps = append(apps, app)
+	}
+
+	parallelism := d.inputs.parallelism
+	if parallelism <= 0 {
+		parallelism = len(apps)
+	}
+	d.action.Infof("deploying %d apps with a parallelism of %d", len(apps), parallelism)
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		sem     = make(chan struct{}, parallelism)
+		results = make([]*deployResult, len(apps))
+		outputs = make(map[string]*appOutput, len(apps))
+		failed  []string
+	)
+	for i, app := range apps {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			var (
+				res *deployResult
+				err = app.err
+			)
+			if err == nil {
+				_, res, err = app.d.runSpec(ctx, app.spec)
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			name := app.spec.GetName()
+			if name == "" {
+				// Fall back to the location if the spec couldn't be read.
+				name = app.location
+			}
+			out := &appOutput{SpecLocation: app.location}
+			if res != nil {
+				out.AppID = res.app.GetID()
+				out.LiveURL = res.app.GetLiveURL()
+				out.DeploymentID = res.deployment.GetID()
+				out.DeploymentPhase = string(res.deployment.GetPhase())
+			}
+			outputs[name] = out
+			results[i] = res
+
+			d.printAppLogs(name, app.logs.Bytes())
+			if err != nil {
+				out.Error = err.Error()
+				failed = append(failed, name)
+				d.action.Errorf("app %q failed: %v", name, err)
+				return
+			}
+			d.action.Infof("app %q finished", name)
+		}()
+	}
+	wg.Wait()
+
+	for _, res := range results {
+		if res != nil && res.app != nil {
+			d.writeSummary(res.app, res.deployment)
+		}
+	}
+
+	outputsJSON, err := json.Marshal(outputs)
+	if err != nil {
+		return fmt.Errorf("failed to marshal apps: %w", err)
+	}
+	d.action.SetOutput("apps", string(outputsJSON))
+
+	if len(failed) > 0 {
+		slices.Sort(failed)
+		return fmt.Errorf("failed to deploy %d of %d apps: %q", len(failed), len(apps), failed)
+	}
+	return nil
+}
+
+// forApp returns a copy of the deployer that deploys the app spec at the given location. Its logs
+// are written to the given writer and its outputs are discarded to not clash with other apps.
+func (d *deployer) forApp(location string, w io.Writer) *deployer {
+	app := *d
+	app.inputs.appSpecLocation = location
+	app.action = gha.New(gha.WithWriter(w), gha.WithGetenv(func(k string) string {
+		switch k {
+		case "GITHUB_OUTPUT", "GITHUB_STEP_SUMMARY":
+			return os.DevNull
+		default:
+			return d.action.Getenv(k)
+		}
+	}))
+	return &app
+}
+
+// syncWriter serializes the writes to the underlying writer, as logs are streamed in the
+// background while the deployment is polled.
+type syncWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// Write writes the given bytes to the underlying writer.
+func (w *syncWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.w.Write(p)
+}
+
+// printAppLogs prints the given logs of an app in a group. GitHub doesn't support nested groups,
+// so groups within the logs are turned into plain headings.
+func (d *deployer) printAppLogs(name string, logs []byte) {
+	d.action.Group(fmt.Sprintf("app %s", name))
+	defer d.action.EndGroup()
+
+	if len(logs) == 0 {
+		return
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(string(logs), "\n"), "\n") {
+		if line == "::endgroup::" {
+			continue
+		}
+		if title, ok := strings.CutPrefix(line, "::group::"); ok {
+			line = "--- " + title
+		}
+		d.action.Infof("%s", line)
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/digitalocean/godo"
+	gha "github.com/sethvargo/go-githubactions"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveSpecLocations(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.yaml", "b.yaml", "c.json"} {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), nil, 0644))
+	}
+
+	tests := []struct {
+		name      string
+		locations []string
+		expected  []string
+		err       bool
+	}{{
+		name:      "single file",
+		locations: []string{filepath.Join(dir, "a.yaml")},
+		expected:  []string{filepath.Join(dir, "a.yaml")},
+	}, {
+		name:      "missing file is kept",
+		locations: []string{filepath.Join(dir, "missing.yaml")},
+		expected:  []string{filepath.Join(dir, "missing.yaml")},
+	}, {
+		name:      "glob",
+		locations: []string{filepath.Join(dir, "*.yaml")},
+		expected:  []string{filepath.Join(dir, "a.yaml"), filepath.Join(dir, "b.yaml")},
+	}, {
+		name:      "list with duplicates",
+		locations: []string{filepath.Join(dir, "c.json"), filepath.Join(dir, "*")},
+		expected:  []string{filepath.Join(dir, "c.json"), filepath.Join(dir, "a.yaml"), filepath.Join(dir, "b.yaml")},
+	}, {
+		name:      "glob without matches",
+		locations: []string{filepath.Join(dir, "*.toml")},
+		err:       true,
+	}, {
+		name:      "invalid glob",
+		locations: []string{"[.yaml"},
+		err:       true,
+	}, {
+		name: "empty",
+		err:  true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := resolveSpecLocations(test.locations)
+			if test.err {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, test.expected, got)
+		})
+	}
+}
+
+func TestDeployApps(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	specs := map[string]string{
+		"foo.yaml":    "name: foo",
+		"bar.yaml":    "name: bar",
+		"broken.yaml": "name: [",
+	}
+	var locations []string
+	for name, content := range specs {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+		locations = append(locations, filepath.Join(dir, name))
+	}
+
+	as := &mockedAppsService{}
+	as.On("List", mock.Anything, mock.Anything).Return([]*godo.App{}, &godo.Response{}, nil)
+	as.On("Propose", mock.Anything, mock.MatchedBy(func(req *godo.AppProposeRequest) bool {
+		return req.Spec.GetName() == "foo"
+	})).Return(&godo.AppProposeResponse{AppCost: 5}, &godo.Response{}, nil)
+	as.On("Propose", mock.Anything, mock.MatchedBy(func(req *godo.AppProposeRequest) bool {
+		return req.Spec.GetName() == "bar"
+	})).Return(&godo.AppProposeResponse{}, &godo.Response{}, &godo.ErrorResponse{Message: "bad spec"})
+
+	var actionLogs bytes.Buffer
+	outputFilePath := t.TempDir() + "/output"
+	d := &deployer{
+		action: gha.New(gha.WithWriter(&actionLogs), gha.WithGetenv(func(k string) string {
+			if k == "GITHUB_OUTPUT" {
+				return outputFilePath
+			}
+			return ""
+		})),
+		apps:   as,
+		inputs: inputs{validateOnly: true, parallelism: 2},
+	}
+
+	err := d.deployApps(ctx, locations)
+	require.EqualError(t, err, `failed to deploy 2 of 3 apps: ["`+filepath.Join(dir, "broken.yaml")+`" "bar"]`)
+
+	logs := actionLogs.String()
+	require.Contains(t, logs, "deploying 3 apps with a parallelism of 2\n")
+	require.Contains(t, logs, `::group::app foo
+app spec is valid, estimated monthly cost: $5.00
+validate_only is set, skipping deployment
+::endgroup::
+app "foo" finished
+`)
+	require.Contains(t, logs, `::group::app bar
+::endgroup::
+::error::app "bar" failed: failed to validate spec: app spec is invalid: bad spec
+`)
+
+	output, err := os.ReadFile(outputFilePath)
+	require.NoError(t, err)
+	require.Equal(t, `apps<<_GitHubActionsFileCommandDelimeter_
+{"`+filepath.Join(dir, "broken.yaml")+`":{"spec_location":"`+filepath.Join(dir, "broken.yaml")+`","error":"failed to create spec: failed to parse app spec: yaml: line 1: did not find expected node content"},"bar":{"spec_location":"`+filepath.Join(dir, "bar.yaml")+`","error":"failed to validate spec: app spec is invalid: bad spec"},"foo":{"spec_location":"`+filepath.Join(dir, "foo.yaml")+`"}}
+_GitHubActionsFileCommandDelimeter_
+`, string(output))
+
+	as.AssertExpectations(t)
+}
+
+func TestDeployAppsFailsOnDuplicateNames(t *testing.T) {
+	dir := t.TempDir()
+	locations := []string{filepath.Join(dir, "a.yaml"), filepath.Join(dir, "b.yaml")}
+	for _, location := range locations {
+		require.NoError(t, os.WriteFile(location, []byte("name: foo"), 0644))
+	}
+
+	// Nothing must be deployed if multiple specs define the same app.
+	as := &mockedAppsService{}
+	d := &deployer{
+		action: gha.New(gha.WithWriter(&bytes.Buffer{})),
+		apps:   as,
+		inputs: inputs{parallelism: 2},
+	}
+
+	err := d.deployApps(context.Background(), locations)
+	require.EqualError(t, err, `app "foo" is defined by both "`+locations[0]+`" and "`+locations[1]+`"`)
+	as.AssertExpectations(t)
+}
+
+func TestPrintAppLogs(t *testing.T) {
+	var actionLogs bytes.Buffer
+	d := &deployer{action: gha.New(gha.WithWriter(&actionLogs))}
+
+	d.printAppLogs("foo", []byte("wait for deployment to finish\n::group::build logs (web)\nbuild log\n::endgroup::\n"))
+	d.printAppLogs("bar", nil)
+
+	require.Equal(t, `::group::app foo
+wait for deployment to finish
+--- build logs (web)
+build log
+::endgroup::
+::group::app bar
+::endgroup::
+`, actionLogs.String())
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/digitalocean/godo"
+)
+
+// setOutputs surfaces the given result as outputs, both as a JSON representation of the app and
+// as individual outputs for the most commonly used fields.
+func (d *deployer) setOutputs(res *deployResult) error {
+	appJSON, err := json.Marshal(res.app)
+	if err != nil {
+		return fmt.Errorf("failed to marshal app: %w", err)
+	}
+	componentURLsJSON, err := json.Marshal(componentURLs(res.app))
+	if err != nil {
+		return fmt.Errorf("failed to marshal component URLs: %w", err)
+	}
+
+	d.action.SetOutput("app", string(appJSON))
+	d.action.SetOutput("app_id", res.app.GetID())
+	d.action.SetOutput("app_name", res.app.GetSpec().GetName())
+	d.action.SetOutput("live_url", res.app.GetLiveURL())
+	d.action.SetOutput("default_ingress", res.app.GetDefaultIngress())
+	d.action.SetOutput("deployment_id", res.deployment.GetID())
+	d.action.SetOutput("deployment_phase", string(res.deployment.GetPhase()))
+	d.action.SetOutput("deployment_cause", res.deployment.GetCause())
+	d.action.SetOutput("was_created", strconv.FormatBool(res.created))
+	d.action.SetOutput("component_urls", string(componentURLsJSON))
+	return nil
+}
+
+// componentURLs returns the URL of every component that's reachable through the app's live URL.
+// Components are reachable through the ingress rules or, in older specs, their own routes.
+func componentURLs(app *godo.App) map[string]string {
+	urls := make(map[string]string)
+	liveURL := strings.TrimSuffix(app.GetLiveURL(), "/")
+	if liveURL == "" {
+		return urls
+	}
+
+	for _, rule := range app.GetSpec().GetIngress().GetRules() {
+		name := rule.GetComponent().GetName()
+		if name == "" || urls[name] != "" {
+			continue
+		}
+		urls[name] = liveURL + rule.GetMatch().GetPath().GetPrefix()
+	}
+	_ = godo.ForEachAppSpecComponent(app.GetSpec(), func(c godo.AppRoutableComponentSpec) error {
+		if routes := c.GetRoutes(); len(routes) > 0 && urls[c.GetName()] == "" {
+			urls[c.GetName()] = liveURL + routes[0].GetPath()
+		}
+		return nil
+	})
+	return urls
+}
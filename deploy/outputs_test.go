@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/digitalocean/godo"
+	gha "github.com/sethvargo/go-githubactions"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetOutputs(t *testing.T) {
+	res := &deployResult{
+		app: &godo.App{
+			ID:             "app-id",
+			LiveURL:        "https://foo.ondigitalocean.app/",
+			DefaultIngress: "https://foo.ondigitalocean.app",
+			Spec: &godo.AppSpec{
+				Name: "foo",
+				Services: []*godo.AppServiceSpec{{
+					Name: "web",
+				}, {
+					Name:   "legacy",
+					Routes: []*godo.AppRouteSpec{{Path: "/legacy"}},
+				}},
+				Workers: []*godo.AppWorkerSpec{{Name: "worker"}},
+				Ingress: &godo.AppIngressSpec{
+					Rules: []*godo.AppIngressSpecRule{{
+						Match:     &godo.AppIngressSpecRuleMatch{Path: &godo.AppIngressSpecRuleStringMatch{Prefix: "/api"}},
+						Component: &godo.AppIngressSpecRuleRoutingComponent{Name: "web"},
+					}},
+				},
+			},
+		},
+		deployment: &godo.Deployment{
+			ID:    "deployment-id",
+			Phase: godo.DeploymentPhase_Active,
+			Cause: "app spec updated",
+		},
+		created: true,
+	}
+
+	outputFilePath := t.TempDir() + "/output"
+	d := &deployer{
+		action: gha.New(gha.WithGetenv(func(k string) string {
+			if k == "GITHUB_OUTPUT" {
+				return outputFilePath
+			}
+			return ""
+		})),
+	}
+	require.NoError(t, d.setOutputs(res))
+
+	appJSON, err := json.Marshal(res.app)
+	require.NoError(t, err)
+	output, err := os.ReadFile(outputFilePath)
+	require.NoError(t, err)
+	require.Equal(t, `app<<_GitHubActionsFileCommandDelimeter_
+`+string(appJSON)+`
+_GitHubActionsFileCommandDelimeter_
+app_id<<_GitHubActionsFileCommandDelimeter_
+app-id
+_GitHubActionsFileCommandDelimeter_
+app_name<<_GitHubActionsFileCommandDelimeter_
+foo
+_GitHubActionsFileCommandDelimeter_
+live_url<<_GitHubActionsFileCommandDelimeter_
+https://foo.ondigitalocean.app/
+_GitHubActionsFileCommandDelimeter_
+default_ingress<<_GitHubActionsFileCommandDelimeter_
+https://foo.ondigitalocean.app
+_GitHubActionsFileCommandDelimeter_
+deployment_id<<_GitHubActionsFileCommandDelimeter_
+deployment-id
+_GitHubActionsFileCommandDelimeter_
+deployment_phase<<_GitHubActionsFileCommandDelimeter_
+ACTIVE
+_GitHubActionsFileCommandDelimeter_
+deployment_cause<<_GitHubActionsFileCommandDelimeter_
+app spec updated
+_GitHubActionsFileCommandDelimeter_
+was_created<<_GitHubActionsFileCommandDelimeter_
+true
+_GitHubActionsFileCommandDelimeter_
+component_urls<<_GitHubActionsFileCommandDelimeter_
+{"legacy":"https://foo.ondigitalocean.app/legacy","web":"https://foo.ondigitalocean.app/api"}
+_GitHubActionsFileCommandDelimeter_
+`, string(output))
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// overlayMergeKeys maps the fields of an app spec that hold lists of objects to the field
+// identifying the objects. Overlays merge into these lists by that key rather than replacing them.
+var overlayMergeKeys = map[string]string{
+	"services":     "name",
+	"workers":      "name",
+	"jobs":         "name",
+	"static_sites": "name",
+	"functions":    "name",
+	"databases":    "name",
+	"envs":         "key",
+}
+
+// overlayDeleteKey marks an item of a merged list in an overlay to be removed if set to true. It
+// avoids a $ prefix to not clash with the expansion of environment variables.
+const overlayDeleteKey = "_delete"
+
+// applyOverlays merges the configured overlay files into the given raw app spec in order. Each
+// overlay is templated the same way as the app spec itself. The merge works on the YAML nodes, so
+// values keep their original text and are parsed just like without overlays. The merged spec is
+// returned as YAML.
+func (d *deployer) applyOverlays(base []byte) ([]byte, error) {
+	merged, err := parseYAMLNode(base)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse app spec: %w", err)
+	}
+
+	for _, path := range d.inputs.appSpecOverlays {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read overlay %q: %w", path, err)
+		}
+		expanded, err := d.expandSpec(path, content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to expand overlay %q: %w", path, err)
+		}
+		if err := d.checkSpecSchema(path, content, expanded, true); err != nil {
+			return nil, fmt.Errorf("invalid overlay %q: %w", path, err)
+		}
+		overlay, err := parseYAMLNode(expanded)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse overlay %q: %w", path, err)
+		}
+		if overlay != nil {
+			merged = mergeOverlay(merged, overlay, "")
+		}
+	}
+
+	if merged == nil {
+		return nil, nil
+	}
+	bs, err := yaml.Marshal(merged)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal merged app spec: %w", err)
+	}
+	return bs, nil
+}
+
+// parseYAMLNode parses the given YAML or JSON document into its root node, with all aliases
+// resolved to copies of the nodes they point to. It returns nil if the document is empty.
+func parseYAMLNode(content []byte) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+	return resolveAliases(doc.Content[0]), nil
+}
+
+// resolveAliases returns a deep copy of the given node with all aliases replaced by copies of the
+// nodes they point to, so merging into a node doesn't affect its aliases.
+func resolveAliases(n *yaml.Node) *yaml.Node {
+	if n.Kind == yaml.AliasNode {
+		return resolveAliases(n.Alias)
+	}
+	res := *n
+	res.Anchor = ""
+	res.Content = make([]*yaml.Node, 0, len(n.Content))
+	for _, c := range n.Content {
+		res.Content = append(res.Content, resolveAliases(c))
+	}
+	return &res
+}
+
+// mergeOverlay merges the given overlay node into the given base node of the given field. Objects
+// are merged recursively and null values remove the respective field. Lists of components and env
+// vars are merged by their name or key, all other values are replaced by the overlay.
+func mergeOverlay(base, overlay *yaml.Node, field string) *yaml.Node {
+	switch overlay.Kind {
+	case yaml.MappingNode:
+		if base == nil || base.Kind != yaml.MappingNode {
+			return overlay
+		}
+		res := *base
+		res.Content = slices.Clone(base.Content)
+		for i := 0; i+1 < len(overlay.Content); i += 2 {
+			k, v := overlay.Content[i], overlay.Content[i+1]
+			idx := mappingIndex(&res, k.Value)
+			switch {
+			case isNull(v):
+				if idx >= 0 {
+					res.Content = slices.Delete(res.Content, idx, idx+2)
+				}
+			case idx < 0:
+				res.Content = append(res.Content, k, v)
+			default:
+				res.Content[idx+1] = mergeOverlay(res.Content[idx+1], v, k.Value)
+			}
+		}
+		return &res
+	case yaml.SequenceNode:
+		key, ok := overlayMergeKeys[field]
+		if !ok || base == nil || base.Kind != yaml.SequenceNode {
+			return overlay
+		}
+		return mergeOverlayList(base, overlay, key)
+	default:
+		return overlay
+	}
+}
+
+// mergeOverlayList merges the items of the given overlay list into the given base list by the
+// given key. Items that don't exist in the base list yet are appended.
+func mergeOverlayList(base, overlay *yaml.Node, key string) *yaml.Node {
+	res := *base
+	res.Content = slices.Clone(base.Content)
+	for _, item := range overlay.Content {
+		id := mappingValue(item, key)
+		if id == nil || isNull(id) {
+			res.Content = append(res.Content, item)
+			continue
+		}
+
+		idx := slices.IndexFunc(res.Content, func(b *yaml.Node) bool {
+			bid := mappingValue(b, key)
+			return bid != nil && bid.Value == id.Value
+		})
+		item, remove := withoutDeleteKey(item)
+
+		switch {
+		case remove:
+			if idx >= 0 {
+				res.Content = slices.Delete(res.Content, idx, idx+1)
+			}
+		case idx < 0:
+			res.Content = append(res.Content, item)
+		default:
+			res.Content[idx] = mergeOverlay(res.Content[idx], item, "")
+		}
+	}
+	return &res
+}
+
+// withoutDeleteKey returns a copy of the given mapping node without the overlayDeleteKey and
+// whether it marked the item to be removed.
+func withoutDeleteKey(n *yaml.Node) (*yaml.Node, bool) {
+	idx := mappingIndex(n, overlayDeleteKey)
+	if idx < 0 {
+		return n, false
+	}
+	var remove bool
+	_ = n.Content[idx+1].Decode(&remove)
+
+	res := *n
+	res.Content = slices.Delete(slices.Clone(n.Content), idx, idx+2)
+	return &res, remove
+}
+
+// mappingIndex returns the index of the given key in the content of the given mapping node, or -1
+// if it doesn't exist.
+func mappingIndex(n *yaml.Node, key string) int {
+	if n.Kind != yaml.MappingNode {
+		return -1
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// mappingValue returns the value of the given key in the given mapping node, or nil if it doesn't
+// exist.
+func mappingValue(n *yaml.Node, key string) *yaml.Node {
+	idx := mappingIndex(n, key)
+	if idx < 0 {
+		return nil
+	}
+	return n.Content[idx+1]
+}
+
+// isNull returns whether the given node is a null value.
+func isNull(n *yaml.Node) bool {
+	return n.Kind == yaml.ScalarNode && n.ShortTag() == "!!null"
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/digitalocean/godo"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestMergeOverlay(t *testing.T) {
+	tests := []struct {
+		name     string
+		base     string
+		overlay  string
+		expected string
+	}{{
+		name:     "merges objects",
+		base:     `{"name":"foo","region":"nyc","ingress":{"rules":[{"match":{"path":{"prefix":"/"}}}]}}`,
+		overlay:  `{"region":"ams","ingress":{"rules":[{"match":{"path":{"prefix":"/api"}}}]}}`,
+		expected: `{"name":"foo","region":"ams","ingress":{"rules":[{"match":{"path":{"prefix":"/api"}}}]}}`,
+	}, {
+		name:     "null removes fields",
+		base:     `{"name":"foo","domains":[{"domain":"example.com"}]}`,
+		overlay:  `{"domains":null}`,
+		expected: `{"name":"foo"}`,
+	}, {
+		name:     "merges components by name",
+		base:     `{"services":[{"name":"web","instance_count":1,"http_port":8080},{"name":"api","instance_count":1}]}`,
+		overlay:  `{"services":[{"name":"api","instance_count":3},{"name":"admin","instance_count":1}]}`,
+		expected: `{"services":[{"name":"web","instance_count":1,"http_port":8080},{"name":"api","instance_count":3},{"name":"admin","instance_count":1}]}`,
+	}, {
+		name:     "merges all component types",
+		base:     `{"workers":[{"name":"w","instance_size_slug":"basic-xxs"}],"jobs":[{"name":"j","kind":"PRE_DEPLOY"}],"static_sites":[{"name":"s","build_command":"npm run build"}],"functions":[{"name":"f","source_dir":"fn"}],"databases":[{"name":"db","engine":"PG"}]}`,
+		overlay:  `{"workers":[{"name":"w","instance_size_slug":"basic-s"}],"jobs":[{"name":"j","kind":"POST_DEPLOY"}],"static_sites":[{"name":"s","output_dir":"dist"}],"functions":[{"name":"f","source_dir":"functions"}],"databases":[{"name":"db","production":true}]}`,
+		expected: `{"workers":[{"name":"w","instance_size_slug":"basic-s"}],"jobs":[{"name":"j","kind":"POST_DEPLOY"}],"static_sites":[{"name":"s","build_command":"npm run build","output_dir":"dist"}],"functions":[{"name":"f","source_dir":"functions"}],"databases":[{"name":"db","engine":"PG","production":true}]}`,
+	}, {
+		name:     "merges env vars by key",
+		base:     `{"envs":[{"key":"A","value":"1"}],"services":[{"name":"web","envs":[{"key":"LOG_LEVEL","value":"debug"},{"key":"PORT","value":"8080"}]}]}`,
+		overlay:  `{"envs":[{"key":"B","value":"2"}],"services":[{"name":"web","envs":[{"key":"LOG_LEVEL","value":"warn"}]}]}`,
+		expected: `{"envs":[{"key":"A","value":"1"},{"key":"B","value":"2"}],"services":[{"name":"web","envs":[{"key":"LOG_LEVEL","value":"warn"},{"key":"PORT","value":"8080"}]}]}`,
+	}, {
+		name:     "deletes items",
+		base:     `{"services":[{"name":"web"},{"name":"debug"}],"envs":[{"key":"A","value":"1"}]}`,
+		overlay:  `{"services":[{"name":"debug","_delete":true},{"name":"missing","_delete":true}],"envs":[{"key":"A","_delete":true}]}`,
+		expected: `{"services":[{"name":"web"}],"envs":[]}`,
+	}, {
+		name:     "replaces other lists",
+		base:     `{"services":[{"name":"web","routes":[{"path":"/"}]}],"alerts":[{"rule":"DEPLOYMENT_FAILED"}]}`,
+		overlay:  `{"services":[{"name":"web","routes":[{"path":"/web"}]}],"alerts":[{"rule":"DOMAIN_FAILED"}]}`,
+		expected: `{"services":[{"name":"web","routes":[{"path":"/web"}]}],"alerts":[{"rule":"DOMAIN_FAILED"}]}`,
+	}, {
+		name:     "adds lists that don't exist in the base",
+		base:     `{"name":"foo"}`,
+		overlay:  `{"workers":[{"name":"w"}]}`,
+		expected: `{"name":"foo","workers":[{"name":"w"}]}`,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			base, err := parseYAMLNode([]byte(test.base))
+			require.NoError(t, err)
+			overlay, err := parseYAMLNode([]byte(test.overlay))
+			require.NoError(t, err)
+
+			merged, err := yaml.Marshal(mergeOverlay(base, overlay, ""))
+			require.NoError(t, err)
+			require.JSONEq(t, test.expected, string(yamlToJSON(t, merged)))
+		})
+	}
+}
+
+// yamlToJSON converts the given YAML document into JSON.
+func yamlToJSON(t *testing.T, content []byte) []byte {
+	var v any
+	require.NoError(t, yaml.Unmarshal(content, &v))
+	bs, err := json.Marshal(v)
+	require.NoError(t, err)
+	return bs
+}
+
+func TestCreateSpecWithOverlays(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"app.yaml": `name: sample
+services:
+- name: web
+  instance_count: 1
+  envs:
+  - key: LOG_LEVEL
+    value: debug
+- name: debug
+`,
+		"staging.yaml": `name: sample-staging
+services:
+- name: web
+  instance_size_slug: basic-s
+  envs:
+  - key: LOG_LEVEL
+    value: info
+- name: debug
+  _delete: true
+`,
+		"scale.yaml": `services:
+- name: web
+  instance_count: ${INSTANCE_COUNT}
+`,
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+	t.Setenv("INSTANCE_COUNT", "3")
+
+	d := &deployer{
+		inputs: inputs{
+			appSpecLocation: filepath.Join(dir, "app.yaml"),
+			appSpecOverlays: []string{filepath.Join(dir, "staging.yaml"), filepath.Join(dir, "scale.yaml")},
+		},
+	}
+	spec, err := d.createSpec(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, &godo.AppSpec{
+		Name: "sample-staging",
+		Services: []*godo.AppServiceSpec{{
+			Name:             "web",
+			InstanceCount:    3,
+			InstanceSizeSlug: "basic-s",
+			Envs:             []*godo.AppVariableDefinition{{Key: "LOG_LEVEL", Value: "info"}},
+		}},
+	}, spec)
+
+	d.inputs.appSpecOverlays = []string{filepath.Join(dir, "missing.yaml")}
+	_, err = d.createSpec(context.Background())
+	require.Error(t, err)
+}
+
+func TestCreateSpecWithOverlaysKeepsValues(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"app.yaml": `name: sample
+envs:
+- key: BIG
+  value: 12345678901234567890
+- key: VERSION
+  value: 1.10
+- key: OCTAL
+  value: 0755
+services:
+- name: web
+  instance_count: 1
+`,
+		"scale.yaml": `services:
+- name: web
+  instance_count: 2
+`,
+	}
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+
+	d := &deployer{inputs: inputs{appSpecLocation: filepath.Join(dir, "app.yaml")}}
+	withoutOverlay, err := d.createSpec(context.Background())
+	require.NoError(t, err)
+
+	// Values the overlay doesn't touch are parsed just like without it.
+	d.inputs.appSpecOverlays = []string{filepath.Join(dir, "scale.yaml")}
+	spec, err := d.createSpec(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, withoutOverlay.Envs, spec.Envs)
+	require.Equal(t, "12345678901234567890", spec.Envs[0].Value)
+	require.EqualValues(t, 2, spec.Services[0].InstanceCount)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/digitalocean/app_actions/utils"
+	"github.com/digitalocean/godo"
+	"sigs.k8s.io/yaml"
+)
+
+// plan resolves the app the given spec belongs to, validates the spec and reports the changes
+// deploying it would cause. The returned app is nil if it does not exist yet.
+func (d *deployer) plan(ctx context.Context, spec *godo.AppSpec) (*godo.App, specDiff, error) {
+	var diff specDiff
+	app, err := utils.FindAppByName(ctx, d.apps, spec.GetName())
+	if err != nil {
+		return nil, diff, fmt.Errorf("failed to get app: %w", err)
+	}
+
+	// Validate the spec before mutating anything to fail fast on invalid specs.
+	if _, err := d.validateSpec(ctx, spec, app.GetID()); err != nil {
+		return nil, diff, fmt.Errorf("failed to validate spec: %w", err)
+	}
+
+	if app != nil {
+		diff, err = d.reportSpecDiff(app.Spec, spec)
+		if err != nil {
+			return nil, diff, fmt.Errorf("failed to diff specs: %w", err)
+		}
+	}
+	return app, diff, nil
+}
+
+// dryRun plans the deployment of the given spec without applying it. The final spec and a
+// summary of the plan are printed and surfaced as outputs.
+func (d *deployer) dryRun(ctx context.Context, spec *godo.AppSpec) error {
+	app, diff, err := d.plan(ctx, spec)
+	if err != nil {
+		return err
+	}
+
+	specYAML, err := yaml.Marshal(spec)
+	if err != nil {
+		return fmt.Errorf("failed to marshal spec: %w", err)
+	}
+	// The spec is surfaced verbatim, so make sure secrets don't end up in the logs.
+	d.maskSecrets(spec)
+
+	d.action.Group("app spec")
+	d.action.Infof(string(specYAML))
+	d.action.EndGroup()
+	d.action.SetOutput("spec", string(specYAML))
+
+	summary := planSummary(spec, app, diff)
+	d.action.Infof(summary)
+	d.action.SetOutput("plan", summary)
+	return nil
+}
+
+// planSummary returns a human-readable summary of what deploying the spec would do.
+func planSummary(spec *godo.AppSpec, app *godo.App, diff specDiff) string {
+	if app == nil {
+		return fmt.Sprintf("app %q would be created", spec.GetName())
+	}
+	if diff.isEmpty() {
+		return fmt.Sprintf("app %q (%s) would be updated without spec changes", spec.GetName(), app.GetID())
+	}
+	return fmt.Sprintf("app %q (%s) would be updated:\n%s", spec.GetName(), app.GetID(), diff)
+}
+
+// maskSecrets masks the values of all secret env vars in the given spec.
+func (d *deployer) maskSecrets(spec *godo.AppSpec) {
+	mask := func(envs []*godo.AppVariableDefinition) {
+		for _, env := range envs {
+			if env.Type == godo.AppVariableType_Secret && env.Value != "" {
+				d.action.AddMask(env.Value)
+			}
+		}
+	}
+
+	mask(spec.Envs)
+	_ = godo.ForEachAppSpecComponent(spec, func(c godo.AppBuildableComponentSpec) error {
+		mask(c.GetEnvs())
+		return nil
+	})
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+
+	"github.com/digitalocean/godo"
+	gha "github.com/sethvargo/go-githubactions"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDryRun(t *testing.T) {
+	ctx := context.Background()
+	appID := "app-id"
+	spec := &godo.AppSpec{
+		Name: "foo",
+		Services: []*godo.AppServiceSpec{{
+			Name:             "web",
+			InstanceSizeSlug: "basic-xs",
+			Envs: []*godo.AppVariableDefinition{{
+				Key:   "TOKEN",
+				Value: "supersecret",
+				Type:  godo.AppVariableType_Secret,
+			}},
+		}},
+	}
+
+	tests := []struct {
+		name           string
+		appService     *mockedAppsService
+		expectedLogs   string
+		expectedOutput string
+	}{{
+		name: "new app",
+		appService: func() *mockedAppsService {
+			as := &mockedAppsService{}
+			as.On("List", ctx, mock.Anything).Return([]*godo.App{}, &godo.Response{}, nil)
+			as.On("Propose", ctx, &godo.AppProposeRequest{Spec: spec}).Return(&godo.AppProposeResponse{AppCost: 5}, &godo.Response{}, nil)
+			return as
+		}(),
+		expectedLogs: `app spec is valid, estimated monthly cost: $5.00
+::add-mask::supersecret
+::group::app spec
+name: foo
+services:
+- envs:
+  - key: TOKEN
+    type: SECRET
+    value: supersecret
+  instance_size_slug: basic-xs
+  name: web
+
+::endgroup::
+app "foo" would be created
+`,
+		expectedOutput: `spec<<_GitHubActionsFileCommandDelimeter_
+name: foo
+services:
+- envs:
+  - key: TOKEN
+    type: SECRET
+    value: supersecret
+  instance_size_slug: basic-xs
+  name: web
+
+_GitHubActionsFileCommandDelimeter_
+plan<<_GitHubActionsFileCommandDelimeter_
+app "foo" would be created
+_GitHubActionsFileCommandDelimeter_
+`,
+	}, {
+		name: "existing app",
+		appService: func() *mockedAppsService {
+			as := &mockedAppsService{}
+			as.On("List", ctx, mock.Anything).Return([]*godo.App{{
+				ID: appID,
+				Spec: &godo.AppSpec{
+					Name: "foo",
+					Services: []*godo.AppServiceSpec{{
+						Name:             "web",
+						InstanceSizeSlug: "basic-xxs",
+						Envs: []*godo.AppVariableDefinition{{
+							Key:   "TOKEN",
+							Value: "supersecret",
+							Type:  godo.AppVariableType_Secret,
+						}},
+					}},
+				},
+			}}, &godo.Response{}, nil)
+			as.On("Propose", ctx, &godo.AppProposeRequest{Spec: spec, AppID: appID}).Return(&godo.AppProposeResponse{AppCost: 5}, &godo.Response{}, nil)
+			return as
+		}(),
+		expectedLogs: `app spec is valid, estimated monthly cost: $5.00
+::group::spec diff
+service "web" changed
+  instance_size_slug: "basic-xxs" -> "basic-xs"
+::endgroup::
+::add-mask::supersecret
+::group::app spec
+name: foo
+services:
+- envs:
+  - key: TOKEN
+    type: SECRET
+    value: supersecret
+  instance_size_slug: basic-xs
+  name: web
+
+::endgroup::
+app "foo" (app-id) would be updated:
+service "web" changed
+  instance_size_slug: "basic-xxs" -> "basic-xs"
+`,
+		expectedOutput: `spec_diff<<_GitHubActionsFileCommandDelimeter_
+{"components":[{"name":"web","type":"service","action":"changed","fields":[{"field":"instance_size_slug","old":"basic-xxs","new":"basic-xs"}]}]}
+_GitHubActionsFileCommandDelimeter_
+spec<<_GitHubActionsFileCommandDelimeter_
+name: foo
+services:
+- envs:
+  - key: TOKEN
+    type: SECRET
+    value: supersecret
+  instance_size_slug: basic-xs
+  name: web
+
+_GitHubActionsFileCommandDelimeter_
+plan<<_GitHubActionsFileCommandDelimeter_
+app "foo" (app-id) would be updated:
+service "web" changed
+  instance_size_slug: "basic-xxs" -> "basic-xs"
+_GitHubActionsFileCommandDelimeter_
+`,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var actionLogs bytes.Buffer
+			outputFilePath := t.TempDir() + "/output"
+			d := &deployer{
+				action: gha.New(gha.WithWriter(&actionLogs), gha.WithGetenv(func(k string) string {
+					if k == "GITHUB_OUTPUT" {
+						return outputFilePath
+					}
+					return ""
+				})),
+				apps: test.appService,
+			}
+
+			err := d.dryRun(ctx, spec)
+			require.NoError(t, err)
+			require.Equal(t, test.expectedLogs, actionLogs.String())
+
+			output, err := os.ReadFile(outputFilePath)
+			require.NoError(t, err)
+			require.Equal(t, test.expectedOutput, string(output))
+
+			// Neither Create nor Update must have been called.
+			test.appService.AssertExpectations(t)
+		})
+	}
+}
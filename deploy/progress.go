@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/digitalocean/godo"
+	gha "github.com/sethvargo/go-githubactions"
+)
+
+// progressReporter logs the changes to the steps of a deployment's progress.
+type progressReporter struct {
+	action *gha.Action
+	// statuses are the last reported statuses by step path.
+	statuses map[string]godo.DeploymentProgressStepStatus
+}
+
+// newProgressReporter returns a new progressReporter.
+func newProgressReporter(a *gha.Action) *progressReporter {
+	return &progressReporter{
+		action:   a,
+		statuses: make(map[string]godo.DeploymentProgressStepStatus),
+	}
+}
+
+// report logs all steps of the given deployment whose status changed since the last report.
+func (r *progressReporter) report(dep *godo.Deployment) {
+	r.reportSteps(dep.GetProgress().GetSteps(), nil)
+}
+
+// reportSteps logs all given steps and their nested steps whose status changed.
+func (r *progressReporter) reportSteps(steps []*godo.DeploymentProgressStep, parents []string) {
+	for _, step := range steps {
+		names := append(parents[:len(parents):len(parents)], step.Name)
+		path := strings.Join(names, " > ")
+
+		if r.statuses[path] != step.Status {
+			r.statuses[path] = step.Status
+			switch step.Status {
+			case godo.DeploymentProgressStepStatus_Running:
+				r.action.Infof("step %q is running", path)
+			case godo.DeploymentProgressStepStatus_Success, godo.DeploymentProgressStepStatus_Error:
+				if d := formatStepDuration(step); d != "" {
+					r.action.Infof("step %q finished with status %s after %s", path, step.Status, d)
+				} else {
+					r.action.Infof("step %q finished with status %s", path, step.Status)
+				}
+			}
+		}
+		r.reportSteps(step.Steps, names)
+	}
+}
+
+// progressStep is a step of a deployment's progress as surfaced by the deployment_progress output.
+type progressStep struct {
+	Name            string                            `json:"name"`
+	Component       string                            `json:"component,omitempty"`
+	Status          godo.DeploymentProgressStepStatus `json:"status"`
+	StartedAt       *time.Time                        `json:"started_at,omitempty"`
+	EndedAt         *time.Time                        `json:"ended_at,omitempty"`
+	DurationSeconds float64                           `json:"duration_seconds,omitempty"`
+	Steps           []progressStep                    `json:"steps,omitempty"`
+}
+
+// toProgressSteps converts the given steps and their nested steps.
+func toProgressSteps(steps []*godo.DeploymentProgressStep) []progressStep {
+	var res []progressStep
+	for _, step := range steps {
+		s := progressStep{
+			Name:      step.Name,
+			Component: step.ComponentName,
+			Status:    step.Status,
+			Steps:     toProgressSteps(step.Steps),
+		}
+		if !step.StartedAt.IsZero() {
+			s.StartedAt = &step.StartedAt
+		}
+		if !step.EndedAt.IsZero() {
+			s.EndedAt = &step.EndedAt
+		}
+		if s.StartedAt != nil && s.EndedAt != nil {
+			s.DurationSeconds = step.EndedAt.Sub(step.StartedAt).Seconds()
+		}
+		res = append(res, s)
+	}
+	return res
+}
+
+// reportProgress surfaces the final progress of the given deployment as an output.
+func (d *deployer) reportProgress(dep *godo.Deployment) error {
+	steps := toProgressSteps(dep.GetProgress().GetSteps())
+	if len(steps) == 0 {
+		return nil
+	}
+	progressJSON, err := json.Marshal(steps)
+	if err != nil {
+		return fmt.Errorf("failed to marshal deployment progress: %w", err)
+	}
+	d.action.SetOutput("deployment_progress", string(progressJSON))
+	return nil
+}
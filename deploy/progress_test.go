@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/digitalocean/godo"
+	gha "github.com/sethvargo/go-githubactions"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProgressReporter(t *testing.T) {
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	deployment := func(buildStatus, webStatus godo.DeploymentProgressStepStatus, ended time.Time) *godo.Deployment {
+		return &godo.Deployment{Progress: &godo.DeploymentProgress{
+			Steps: []*godo.DeploymentProgressStep{{
+				Name:      "build",
+				Status:    buildStatus,
+				StartedAt: start,
+				EndedAt:   ended,
+				Steps: []*godo.DeploymentProgressStep{{
+					Name:          "web",
+					ComponentName: "web",
+					Status:        webStatus,
+					StartedAt:     start,
+					EndedAt:       ended,
+				}},
+			}, {
+				Name:   "deploy",
+				Status: godo.DeploymentProgressStepStatus_Pending,
+			}},
+		}}
+	}
+
+	var actionLogs bytes.Buffer
+	r := newProgressReporter(gha.New(gha.WithWriter(&actionLogs)))
+	r.report(deployment(godo.DeploymentProgressStepStatus_Running, godo.DeploymentProgressStepStatus_Pending, time.Time{}))
+	r.report(deployment(godo.DeploymentProgressStepStatus_Running, godo.DeploymentProgressStepStatus_Running, time.Time{}))
+	// Unchanged steps are not reported again.
+	r.report(deployment(godo.DeploymentProgressStepStatus_Running, godo.DeploymentProgressStepStatus_Running, time.Time{}))
+	r.report(deployment(godo.DeploymentProgressStepStatus_Success, godo.DeploymentProgressStepStatus_Success, start.Add(90*time.Second)))
+
+	require.Equal(t, `step "build" is running
+step "build > web" is running
+step "build" finished with status SUCCESS after 1m30s
+step "build > web" finished with status SUCCESS after 1m30s
+`, actionLogs.String())
+}
+
+func TestReportProgress(t *testing.T) {
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	dep := &godo.Deployment{Progress: &godo.DeploymentProgress{
+		Steps: []*godo.DeploymentProgressStep{{
+			Name:      "build",
+			Status:    godo.DeploymentProgressStepStatus_Success,
+			StartedAt: start,
+			EndedAt:   start.Add(90 * time.Second),
+			Steps: []*godo.DeploymentProgressStep{{
+				Name:          "web",
+				ComponentName: "web",
+				Status:        godo.DeploymentProgressStepStatus_Success,
+				StartedAt:     start,
+				EndedAt:       start.Add(80 * time.Second),
+			}},
+		}, {
+			Name:   "deploy",
+			Status: godo.DeploymentProgressStepStatus_Pending,
+		}},
+	}}
+
+	outputFilePath := t.TempDir() + "/output"
+	d := &deployer{
+		action: gha.New(gha.WithGetenv(func(k string) string {
+			if k == "GITHUB_OUTPUT" {
+				return outputFilePath
+			}
+			return ""
+		})),
+	}
+	require.NoError(t, d.reportProgress(dep))
+
+	output, err := os.ReadFile(outputFilePath)
+	require.NoError(t, err)
+	require.Equal(t, `deployment_progress<<_GitHubActionsFileCommandDelimeter_
+[{"name":"build","status":"SUCCESS","started_at":"2024-05-01T10:00:00Z","ended_at":"2024-05-01T10:01:30Z","duration_seconds":90,"steps":[{"name":"web","component":"web","status":"SUCCESS","started_at":"2024-05-01T10:00:00Z","ended_at":"2024-05-01T10:01:20Z","duration_seconds":80}]},{"name":"deploy","status":"PENDING"}]
+_GitHubActionsFileCommandDelimeter_
+`, string(output))
+}
+
+func TestProgressIsReportedWhileStreamingLogs(t *testing.T) {
+	ctx := context.Background()
+	appID := "app-id"
+	deploymentID := "deployment-id"
+	deployment := func(phase godo.DeploymentPhase, buildStatus godo.DeploymentProgressStepStatus) *godo.Deployment {
+		return &godo.Deployment{ID: deploymentID, Phase: phase, Progress: &godo.DeploymentProgress{
+			Steps: []*godo.DeploymentProgressStep{{Name: "build", Status: buildStatus}},
+		}}
+	}
+
+	as := &mockedAppsService{}
+	as.On("GetDeployment", mock.Anything, appID, deploymentID).Return(deployment(godo.DeploymentPhase_Building, godo.DeploymentProgressStepStatus_Running), &godo.Response{}, nil).Once()
+	as.On("GetDeployment", mock.Anything, appID, deploymentID).Return(deployment(godo.DeploymentPhase_Active, godo.DeploymentProgressStepStatus_Success), &godo.Response{}, nil).Once()
+	// The stream stays open until the deployment finished.
+	chunks := make(chan string)
+	srv := newLogServer(t, chunks)
+	as.On("GetLogs", ctx, appID, deploymentID, "", godo.AppLogTypeBuild, true, -1).Return(&godo.AppLogs{
+		LiveURL: srv.URL,
+	}, &godo.Response{}, nil)
+
+	var actionLogs bytes.Buffer
+	d := &deployer{
+		action:     gha.New(gha.WithWriter(&syncWriter{w: &actionLogs})),
+		apps:       as,
+		httpClient: srv.Client(),
+		inputs:     inputs{printBuildLogs: true},
+	}
+
+	streamer := d.newLogStreamer(ctx, appID, deploymentID)
+	dep, err := d.waitForDeploymentTerminal(ctx, appID, deploymentID, streamer.onUpdate)
+	require.NoError(t, err)
+	require.Equal(t, godo.DeploymentPhase_Active, dep.GetPhase())
+
+	chunks <- "build done\n"
+	close(chunks)
+	streamer.wait()
+
+	require.Equal(t, `deployment is in phase: BUILDING
+step "build" is running
+deployment is in phase: ACTIVE
+step "build" finished with status SUCCESS
+[build] build done
+`, actionLogs.String())
+	as.AssertExpectations(t)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/digitalocean/app_actions/utils"
+	"github.com/digitalocean/godo"
+)
+
+// rollback rolls the given app back to its last active deployment after the given deployment
+// failed. It waits for the rollback to finish and returns the restored deployment.
+func (d *deployer) rollback(ctx context.Context, app *godo.App, failedDeploymentID string) (*godo.Deployment, error) {
+	target := app.GetActiveDeployment()
+	if target == nil || target.GetID() == failedDeploymentID {
+		return nil, errors.New("no previous active deployment to roll back to")
+	}
+	if err := d.rollbackTo(ctx, app.GetID(), target); err != nil {
+		return nil, err
+	}
+	return target, nil
+}
+
+// rollbackTo rolls the given app back to the given deployment and waits for the rollback to finish.
+func (d *deployer) rollbackTo(ctx context.Context, appID string, target *godo.Deployment) error {
+	// Skip pinning the app to the rolled back deployment to not block subsequent deployments.
+	req := &utils.RollbackRequest{DeploymentID: target.GetID(), SkipPin: true}
+	rollbackDep, err := utils.ExecuteRollback(ctx, d.action, d.rollbacks, appID, req)
+	if err != nil {
+		return err
+	}
+
+	d.action.Infof("wait for rollback to finish")
+	dep, err := d.waitForDeploymentTerminal(ctx, appID, rollbackDep.GetID(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to wait for rollback to finish: %w", err)
+	}
+	if dep.GetPhase() != godo.DeploymentPhase_Active {
+		return fmt.Errorf("rollback failed: %s", dep.GetPhase())
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/digitalocean/app_actions/utils"
+	"github.com/digitalocean/godo"
+	gha "github.com/sethvargo/go-githubactions"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRollback(t *testing.T) {
+	ctx := context.Background()
+	appID := "app-id"
+	failedID := "failed-id"
+	activeID := "active-id"
+	rollbackID := "rollback-id"
+	app := &godo.App{ID: appID, ActiveDeployment: &godo.Deployment{ID: activeID}}
+	req := &utils.RollbackRequest{DeploymentID: activeID, SkipPin: true}
+
+	tests := []struct {
+		name         string
+		app          *godo.App
+		appService   *mockedAppsService
+		rollbacks    *mockedRollbackService
+		expectedLogs string
+		err          bool
+	}{{
+		name:       "success",
+		app:        app,
+		appService: appServiceWithDeployment(ctx, appID, rollbackID, godo.DeploymentPhase_Active),
+		rollbacks: func() *mockedRollbackService {
+			rs := &mockedRollbackService{}
+			rs.On("ValidateRollback", ctx, appID, req).Return(&utils.RollbackValidation{
+				Valid:    true,
+				Warnings: []*utils.RollbackValidationCondition{{Message: "a warning"}},
+			}, &godo.Response{}, nil)
+			rs.On("Rollback", ctx, appID, req).Return(&godo.Deployment{ID: rollbackID}, &godo.Response{}, nil)
+			return rs
+		}(),
+		expectedLogs: `::warning::rollback warning: a warning
+rolling back to deployment active-id
+wait for rollback to finish
+deployment is in phase: ACTIVE
+`,
+	}, {
+		name:       "no active deployment",
+		app:        &godo.App{ID: appID},
+		appService: &mockedAppsService{},
+		rollbacks:  &mockedRollbackService{},
+		err:        true,
+	}, {
+		name:       "active deployment is the failed deployment",
+		app:        &godo.App{ID: appID, ActiveDeployment: &godo.Deployment{ID: failedID}},
+		appService: &mockedAppsService{},
+		rollbacks:  &mockedRollbackService{},
+		err:        true,
+	}, {
+		name:       "invalid rollback",
+		app:        app,
+		appService: &mockedAppsService{},
+		rollbacks: func() *mockedRollbackService {
+			rs := &mockedRollbackService{}
+			rs.On("ValidateRollback", ctx, appID, req).Return(&utils.RollbackValidation{
+				Error: &utils.RollbackValidationCondition{Message: "not possible"},
+			}, &godo.Response{}, nil)
+			return rs
+		}(),
+		err: true,
+	}, {
+		name:       "fails to roll back",
+		app:        app,
+		appService: &mockedAppsService{},
+		rollbacks: func() *mockedRollbackService {
+			rs := &mockedRollbackService{}
+			rs.On("ValidateRollback", ctx, appID, req).Return(&utils.RollbackValidation{Valid: true}, &godo.Response{}, nil)
+			rs.On("Rollback", ctx, appID, req).Return(&godo.Deployment{}, &godo.Response{}, errors.New("an error"))
+			return rs
+		}(),
+		err: true,
+		expectedLogs: `rolling back to deployment active-id
+`,
+	}, {
+		name:       "rollback deployment fails",
+		app:        app,
+		appService: appServiceWithDeployment(ctx, appID, rollbackID, godo.DeploymentPhase_Error),
+		rollbacks: func() *mockedRollbackService {
+			rs := &mockedRollbackService{}
+			rs.On("ValidateRollback", ctx, appID, req).Return(&utils.RollbackValidation{Valid: true}, &godo.Response{}, nil)
+			rs.On("Rollback", ctx, appID, req).Return(&godo.Deployment{ID: rollbackID}, &godo.Response{}, nil)
+			return rs
+		}(),
+		err: true,
+		expectedLogs: `rolling back to deployment active-id
+wait for rollback to finish
+deployment is in phase: ERROR
+`,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var actionLogs bytes.Buffer
+			d := &deployer{
+				action:    gha.New(gha.WithWriter(&actionLogs)),
+				apps:      test.appService,
+				rollbacks: test.rollbacks,
+			}
+
+			restored, err := d.rollback(ctx, test.app, failedID)
+			if test.err {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, activeID, restored.GetID())
+			}
+			require.Equal(t, test.expectedLogs, actionLogs.String())
+
+			test.appService.AssertExpectations(t)
+			test.rollbacks.AssertExpectations(t)
+		})
+	}
+}
+
+// appServiceWithDeployment returns an apps service that returns a deployment in the given phase.
+func appServiceWithDeployment(ctx context.Context, appID, deploymentID string, phase godo.DeploymentPhase) *mockedAppsService {
+	as := &mockedAppsService{}
+	as.On("GetDeployment", ctx, appID, deploymentID).Return(&godo.Deployment{ID: deploymentID, Phase: phase}, &godo.Response{}, nil)
+	return as
+}
+
+type mockedRollbackService struct {
+	mock.Mock
+}
+
+func (m *mockedRollbackService) ValidateRollback(ctx context.Context, appID string, req *utils.RollbackRequest) (*utils.RollbackValidation, *godo.Response, error) {
+	args := m.Called(ctx, appID, req)
+	return args.Get(0).(*utils.RollbackValidation), args.Get(1).(*godo.Response), args.Error(2)
+}
+
+func (m *mockedRollbackService) Rollback(ctx context.Context, appID string, req *utils.RollbackRequest) (*godo.Deployment, *godo.Response, error) {
+	args := m.Called(ctx, appID, req)
+	return args.Get(0).(*godo.Deployment), args.Get(1).(*godo.Response), args.Error(2)
+}
+
+func (m *mockedRollbackService) CommitRollback(ctx context.Context, appID string) (*godo.Response, error) {
+	args := m.Called(ctx, appID)
+	return args.Get(0).(*godo.Response), args.Error(1)
+}
+
+func (m *mockedRollbackService) RevertRollback(ctx context.Context, appID string) (*godo.Deployment, *godo.Response, error) {
+	args := m.Called(ctx, appID)
+	return args.Get(0).(*godo.Deployment), args.Get(1).(*godo.Response), args.Error(2)
+}
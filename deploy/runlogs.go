@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/digitalocean/godo"
+)
+
+// tailRunLogs follows the run logs of every service for the configured duration and fails if any
+// of the lines match one of the configured error patterns. This catches services that crash
+// shortly after they went live. It also fails if the run logs of a service can't be tailed, as
+// that would otherwise let such crashes go unnoticed.
+func (d *deployer) tailRunLogs(ctx context.Context, appID, deploymentID string, spec *godo.AppSpec) error {
+	d.action.Infof("tailing run logs for %s", d.inputs.tailRunLogs)
+
+	tailCtx, cancel := context.WithTimeout(ctx, d.inputs.tailRunLogs)
+	defer cancel()
+
+	services := spec.GetServices()
+	logs := make([][]string, len(services))
+	errs := make([]error, len(services))
+	var wg sync.WaitGroup
+	for i, svc := range services {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			logs[i], errs[i] = d.tailComponentRunLogs(tailCtx, appID, deploymentID, svc.GetName())
+		}()
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var failed, untailed []string
+	for i, svc := range services {
+		if errs[i] != nil {
+			d.action.Errorf("failed to tail run logs of service %q: %v", svc.GetName(), errs[i])
+			untailed = append(untailed, svc.GetName())
+		}
+		if len(logs[i]) == 0 {
+			continue
+		}
+
+		d.action.Group(fmt.Sprintf("run logs (%s)", svc.GetName()))
+		for _, line := range logs[i] {
+			d.action.Infof("%s", line)
+		}
+		d.action.EndGroup()
+
+		matched := false
+		for _, line := range logs[i] {
+			for _, re := range d.inputs.runLogErrorPatterns {
+				if re.MatchString(line) {
+					d.action.Errorf("service %q logged an error matching %q: %s", svc.GetName(), re.String(), line)
+					matched = true
+					break
+				}
+			}
+		}
+		if matched {
+			failed = append(failed, svc.GetName())
+		}
+	}
+	if len(untailed) > 0 {
+		return fmt.Errorf("failed to tail run logs of services %q", untailed)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("run logs of services %q matched error patterns", failed)
+	}
+	return nil
+}
+
+// tailComponentRunLogs collects the run logs of the given component until the context is done.
+func (d *deployer) tailComponentRunLogs(ctx context.Context, appID, deploymentID, component string) ([]string, error) {
+	logs, resp, err := d.apps.GetLogs(ctx, appID, deploymentID, component, godo.AppLogTypeRun, true, -1)
+	if err != nil {
+		// Ignore if we get a 400, as this means the component doesn't have run logs.
+		if resp != nil && resp.StatusCode == http.StatusBadRequest {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get run logs: %w", err)
+	}
+	if logs.LiveURL == "" {
+		return nil, nil
+	}
+
+	var lines []string
+	err = d.streamLogs(ctx, logs.LiveURL, func(line string) {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	})
+	// The stream is expected to be cut off once the tail duration is over.
+	if err != nil && !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return lines, err
+	}
+	return lines, nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/digitalocean/godo"
+	gha "github.com/sethvargo/go-githubactions"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTailRunLogs(t *testing.T) {
+	ctx := context.Background()
+	appID := "app-id"
+	deploymentID := "deployment-id"
+	spec := &godo.AppSpec{
+		Name:     "foo",
+		Services: []*godo.AppServiceSpec{{Name: "web"}, {Name: "api"}},
+	}
+
+	tests := []struct {
+		name         string
+		webLogs      string
+		webRejected  bool
+		expectedLogs string
+		err          string
+	}{{
+		name:    "no errors",
+		webLogs: "starting\nlistening on :8080\n",
+		expectedLogs: `tailing run logs for 1s
+::group::run logs (web)
+starting
+listening on :8080
+::endgroup::
+`,
+	}, {
+		name:    "errors",
+		webLogs: "starting\npanic: boom\n",
+		expectedLogs: `tailing run logs for 1s
+::group::run logs (web)
+starting
+panic: boom
+::endgroup::
+::error::service "web" logged an error matching "panic:": panic: boom
+`,
+		err: `run logs of services ["web"] matched error patterns`,
+	}, {
+		name:        "fails if logs can't be tailed",
+		webRejected: true,
+		expectedLogs: `tailing run logs for 1s
+::error::failed to tail run logs of service "web": failed to get live logs: unexpected status 401 Unauthorized
+`,
+		err: `failed to tail run logs of services ["web"]`,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			srv := newLogServer(t, logChunks(test.webLogs))
+			if test.webRejected {
+				// The error body must not be matched against the error patterns.
+				srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+					http.Error(w, "panic: unauthorized", http.StatusUnauthorized)
+				}))
+				t.Cleanup(srv.Close)
+			}
+			as := &mockedAppsService{}
+			as.On("GetLogs", mock.Anything, appID, deploymentID, "web", godo.AppLogTypeRun, true, -1).Return(&godo.AppLogs{
+				LiveURL: srv.URL,
+			}, &godo.Response{}, nil)
+			// The api service doesn't have run logs yet.
+			as.On("GetLogs", mock.Anything, appID, deploymentID, "api", godo.AppLogTypeRun, true, -1).Return(&godo.AppLogs{}, &godo.Response{Response: &http.Response{StatusCode: http.StatusBadRequest}}, errors.New("an error"))
+
+			var actionLogs bytes.Buffer
+			d := &deployer{
+				action:     gha.New(gha.WithWriter(&actionLogs)),
+				apps:       as,
+				httpClient: srv.Client(),
+				inputs: inputs{
+					tailRunLogs:         time.Second,
+					runLogErrorPatterns: []*regexp.Regexp{regexp.MustCompile("panic:"), regexp.MustCompile("FATAL")},
+				},
+			}
+
+			err := d.tailRunLogs(ctx, appID, deploymentID, spec)
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, test.expectedLogs, actionLogs.String())
+			as.AssertExpectations(t)
+		})
+	}
+}
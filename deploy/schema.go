@@ -0,0 +1,372 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/digitalocean/godo"
+	"gopkg.in/yaml.v3"
+)
+
+// specEnums are the valid values of the enum types used in app specs.
+var specEnums = map[reflect.Type][]string{
+	reflect.TypeFor[godo.AppAlertSpecOperator](): enumValues(
+		godo.AppAlertSpecOperator_UnspecifiedOperator,
+		godo.AppAlertSpecOperator_GreaterThan,
+		godo.AppAlertSpecOperator_LessThan,
+	),
+	reflect.TypeFor[godo.AppAlertSpecRule](): enumValues(
+		godo.AppAlertSpecRule_UnspecifiedRule,
+		godo.AppAlertSpecRule_CPUUtilization,
+		godo.AppAlertSpecRule_MemUtilization,
+		godo.AppAlertSpecRule_RestartCount,
+		godo.AppAlertSpecRule_DeploymentFailed,
+		godo.AppAlertSpecRule_DeploymentLive,
+		godo.AppAlertSpecRule_DeploymentStarted,
+		godo.AppAlertSpecRule_DeploymentCanceled,
+		godo.AppAlertSpecRule_DomainFailed,
+		godo.AppAlertSpecRule_DomainLive,
+		godo.AppAlertSpecRule_FunctionsActivationCount,
+		godo.AppAlertSpecRule_FunctionsAverageDurationMS,
+		godo.AppAlertSpecRule_FunctionsErrorRatePerMinute,
+		godo.AppAlertSpecRule_FunctionsAverageWaitTimeMs,
+		godo.AppAlertSpecRule_FunctionsErrorCount,
+		godo.AppAlertSpecRule_FunctionsGBRatePerSecond,
+	),
+	reflect.TypeFor[godo.AppAlertSpecWindow](): enumValues(
+		godo.AppAlertSpecWindow_UnspecifiedWindow,
+		godo.AppAlertSpecWindow_FiveMinutes,
+		godo.AppAlertSpecWindow_TenMinutes,
+		godo.AppAlertSpecWindow_ThirtyMinutes,
+		godo.AppAlertSpecWindow_OneHour,
+	),
+	reflect.TypeFor[godo.AppDatabaseSpecEngine](): enumValues(
+		godo.AppDatabaseSpecEngine_Unset,
+		godo.AppDatabaseSpecEngine_MySQL,
+		godo.AppDatabaseSpecEngine_PG,
+		godo.AppDatabaseSpecEngine_Redis,
+		godo.AppDatabaseSpecEngine_MongoDB,
+		godo.AppDatabaseSpecEngine_Kafka,
+		godo.AppDatabaseSpecEngine_Opensearch,
+	),
+	reflect.TypeFor[godo.AppDomainSpecType](): enumValues(
+		godo.AppDomainSpecType_Unspecified,
+		godo.AppDomainSpecType_Default,
+		godo.AppDomainSpecType_Primary,
+		godo.AppDomainSpecType_Alias,
+	),
+	reflect.TypeFor[godo.AppEgressSpecType](): enumValues(
+		godo.APPEGRESSSPECTYPE_Autoassign,
+		godo.APPEGRESSSPECTYPE_DedicatedIp,
+	),
+	reflect.TypeFor[godo.AppIngressSpecLoadBalancer](): enumValues(
+		godo.AppIngressSpecLoadBalancer_Unknown,
+		godo.AppIngressSpecLoadBalancer_DigitalOcean,
+	),
+	reflect.TypeFor[godo.AppJobSpecKind](): enumValues(
+		godo.AppJobSpecKind_Unspecified,
+		godo.AppJobSpecKind_PreDeploy,
+		godo.AppJobSpecKind_PostDeploy,
+		godo.AppJobSpecKind_FailedDeploy,
+	),
+	reflect.TypeFor[godo.ImageSourceSpecRegistryType](): enumValues(
+		godo.ImageSourceSpecRegistryType_Unspecified,
+		godo.ImageSourceSpecRegistryType_DOCR,
+		godo.ImageSourceSpecRegistryType_DockerHub,
+		godo.ImageSourceSpecRegistryType_Ghcr,
+	),
+	reflect.TypeFor[godo.AppVariableScope](): enumValues(
+		godo.AppVariableScope_Unset,
+		godo.AppVariableScope_RunTime,
+		godo.AppVariableScope_BuildTime,
+		godo.AppVariableScope_RunAndBuildTime,
+	),
+	reflect.TypeFor[godo.AppVariableType](): enumValues(
+		godo.AppVariableType_General,
+		godo.AppVariableType_Secret,
+	),
+}
+
+// enumValues returns the string values of the given enum values.
+func enumValues[T ~string](values ...T) []string {
+	res := make([]string, 0, len(values))
+	for _, v := range values {
+		res = append(res, string(v))
+	}
+	return res
+}
+
+// yaml11Bools are the values YAML 1.1, which is used to parse app specs, treats as booleans on
+// top of the ones YAML 1.2 knows.
+var yaml11Bools = []string{"y", "Y", "yes", "Yes", "YES", "n", "N", "no", "No", "NO", "on", "On", "ON", "off", "Off", "OFF"}
+
+// reportSpecIssues emits the given schema issues of the given app spec file as annotations. The
+// file may be empty if the spec didn't come from a file. If the spec was rendered in a way that
+// may have shifted its lines, the positions don't match the file, so they're only mentioned in
+// the message.
+func (d *deployer) reportSpecIssues(file string, rendered bool, issues []specIssue) {
+	for _, issue := range issues {
+		fields := map[string]string{"title": "Invalid app spec"}
+		if rendered {
+			name := file
+			if name == "" {
+				name = "app_spec"
+			}
+			d.action.WithFieldsMap(fields).Errorf("%s: %s (at line %d, column %d of the rendered %s)", issue.path, issue.message, issue.line, issue.column, name)
+			continue
+		}
+
+		fields["line"] = strconv.Itoa(issue.line)
+		fields["col"] = strconv.Itoa(issue.column)
+		if file != "" {
+			fields["file"] = file
+		}
+		d.action.WithFieldsMap(fields).Errorf("%s: %s", issue.path, issue.message)
+	}
+}
+
+// checkSpecSchema validates the given expanded app spec against its schema, reports all issues as
+// annotations and fails if there are any. The raw spec is used to tell whether the positions of
+// the issues still match the file.
+func (d *deployer) checkSpecSchema(file string, raw, expanded []byte, overlay bool) error {
+	issues, err := validateSpecSchema(expanded, overlay)
+	if err != nil {
+		return fmt.Errorf("failed to parse app spec: %w", err)
+	}
+	if len(issues) > 0 {
+		// Go templates can add and remove lines at will, while expanding env vars only shifts
+		// lines if their values span multiple lines.
+		rendered := d.inputs.appSpecTemplating == specTemplatingGo || bytes.Count(raw, []byte("\n")) != bytes.Count(expanded, []byte("\n"))
+		d.reportSpecIssues(file, rendered, issues)
+		return fmt.Errorf("app spec doesn't match the schema, %d issue(s) reported", len(issues))
+	}
+	return nil
+}
+
+// specIssue is a problem found while validating an app spec against its schema.
+type specIssue struct {
+	// path is the path of the offending field, for example services[0].instance_size_slug.
+	path    string
+	line    int
+	column  int
+	message string
+}
+
+// validateSpecSchema validates the given YAML or JSON app spec against the schema of the app spec
+// as defined by godo. It reports unknown fields, values of the wrong type and invalid enum values
+// along with their location. If overlay is set, the spec is validated as an overlay, which may
+// mark items to be deleted.
+func validateSpecSchema(content []byte, overlay bool) ([]specIssue, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+
+	v := &schemaValidator{overlay: overlay}
+	v.validate(doc.Content[0], reflect.TypeFor[godo.AppSpec](), "")
+	return v.issues, nil
+}
+
+// schemaValidator walks a YAML document alongside the Go type it's unmarshalled into.
+type schemaValidator struct {
+	overlay bool
+	issues  []specIssue
+}
+
+// report records an issue with the given node.
+func (v *schemaValidator) report(n *yaml.Node, path, format string, args ...any) {
+	v.issues = append(v.issues, specIssue{
+		path:    path,
+		line:    n.Line,
+		column:  n.Column,
+		message: fmt.Sprintf(format, args...),
+	})
+}
+
+// validate validates the given node against the given type.
+func (v *schemaValidator) validate(n *yaml.Node, t reflect.Type, path string) {
+	if n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+	if n.Kind == yaml.ScalarNode && n.Tag == "!!null" {
+		return
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if n.Kind != yaml.MappingNode {
+			v.report(n, path, "expected an object, got %s", nodeType(n))
+			return
+		}
+		v.validateStruct(n, t, path)
+	case reflect.Slice:
+		if n.Kind != yaml.SequenceNode {
+			v.report(n, path, "expected a list, got %s", nodeType(n))
+			return
+		}
+		for i, item := range n.Content {
+			v.validate(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i))
+		}
+	case reflect.Map:
+		if n.Kind != yaml.MappingNode {
+			v.report(n, path, "expected an object, got %s", nodeType(n))
+			return
+		}
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			v.validate(n.Content[i+1], t.Elem(), joinPath(path, n.Content[i].Value))
+		}
+	case reflect.String:
+		if n.Kind != yaml.ScalarNode {
+			v.report(n, path, "expected a string, got %s", nodeType(n))
+			return
+		}
+		if values, ok := specEnums[t]; ok && n.Value != "" && !slices.Contains(values, n.Value) {
+			v.report(n, path, "invalid value %q, expected one of %s", n.Value, strings.Join(values, ", "))
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if n.Kind != yaml.ScalarNode || n.Tag != "!!int" {
+			v.report(n, path, "expected an integer, got %s", nodeType(n))
+		}
+	case reflect.Float32, reflect.Float64:
+		if n.Kind != yaml.ScalarNode || (n.Tag != "!!int" && n.Tag != "!!float") {
+			v.report(n, path, "expected a number, got %s", nodeType(n))
+		}
+	case reflect.Bool:
+		if n.Kind != yaml.ScalarNode || (n.Tag != "!!bool" && !slices.Contains(yaml11Bools, n.Value)) {
+			v.report(n, path, "expected a boolean, got %s", nodeType(n))
+		}
+	}
+}
+
+// validateStruct validates the fields of the given mapping node against the given struct type.
+func (v *schemaValidator) validateStruct(n *yaml.Node, t reflect.Type, path string) {
+	fields := jsonFields(t)
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key, value := n.Content[i], n.Content[i+1]
+		if key.Value == "<<" {
+			// Merge keys pull in the fields of another object.
+			v.validate(value, t, path)
+			continue
+		}
+		if v.overlay && key.Value == overlayDeleteKey {
+			continue
+		}
+
+		field, ok := fields[key.Value]
+		if !ok {
+			// Like encoding/json, accept keys that only differ in case.
+			for name, f := range fields {
+				if strings.EqualFold(name, key.Value) {
+					field, ok = f, true
+					break
+				}
+			}
+		}
+		if !ok {
+			msg := fmt.Sprintf("unknown field %q", key.Value)
+			if suggestion := closestField(key.Value, fields); suggestion != "" {
+				msg += fmt.Sprintf(", did you mean %q?", suggestion)
+			}
+			v.report(key, joinPath(path, key.Value), "%s", msg)
+			continue
+		}
+		v.validate(value, field, joinPath(path, key.Value))
+	}
+}
+
+// jsonFields returns the types of the fields of the given struct type by their JSON name.
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type, t.NumField())
+	for _, f := range reflect.VisibleFields(t) {
+		if !f.IsExported() || f.Anonymous {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = f.Name
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+// closestField returns the field name closest to the given unknown key, if there's one that's
+// likely to be a typo of it.
+func closestField(key string, fields map[string]reflect.Type) string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	var best string
+	// Only suggest fields within a few edits of the key.
+	bestDist := len(key)/3 + 1
+	for _, name := range names {
+		if dist := levenshtein(key, name); dist < bestDist {
+			best, bestDist = name, dist
+		}
+	}
+	return best
+}
+
+// levenshtein returns the edit distance between the given strings.
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// joinPath appends the given field to the given path.
+func joinPath(path, field string) string {
+	if path == "" {
+		return field
+	}
+	return path + "." + field
+}
+
+// nodeType describes the type of the given node for error messages.
+func nodeType(n *yaml.Node) string {
+	switch n.Kind {
+	case yaml.MappingNode:
+		return "an object"
+	case yaml.SequenceNode:
+		return "a list"
+	}
+	switch n.Tag {
+	case "!!int":
+		return fmt.Sprintf("integer %s", n.Value)
+	case "!!float":
+		return fmt.Sprintf("number %s", n.Value)
+	case "!!bool":
+		return fmt.Sprintf("boolean %s", n.Value)
+	}
+	return fmt.Sprintf("string %q", n.Value)
+}
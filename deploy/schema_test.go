@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	gha "github.com/sethvargo/go-githubactions"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateSpecSchema(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     string
+		overlay  bool
+		expected []specIssue
+		err      bool
+	}{{
+		name: "valid",
+		spec: `name: sample
+region: nyc
+services:
+- name: web
+  instance_count: 2
+  instance_size_slug: basic-xxs
+  http_port: 8080
+  image:
+    registry_type: GHCR
+    registry: foo
+    repository: bar
+    tag: latest
+  envs:
+  - key: PORT
+    value: 8080
+    scope: RUN_TIME
+    type: GENERAL
+  - key: DEBUG
+    value: ${DEBUG}
+    type:
+jobs:
+- name: migrate
+  kind: PRE_DEPLOY
+databases:
+- name: db
+  engine: PG
+  production: yes
+alerts:
+- rule: CPU_UTILIZATION
+  value: 80.5
+`,
+	}, {
+		name: "JSON",
+		spec: `{"name":"sample","services":[{"name":"web","instance_count":2}]}`,
+	}, {
+		name: "empty",
+		spec: "",
+	}, {
+		name: "unknown fields",
+		spec: `name: sample
+services:
+- name: web
+  instance_size_slg: basic-xxs
+  foo: bar
+`,
+		expected: []specIssue{{
+			path:    "services[0].instance_size_slg",
+			line:    4,
+			column:  3,
+			message: `unknown field "instance_size_slg", did you mean "instance_size_slug"?`,
+		}, {
+			path:    "services[0].foo",
+			line:    5,
+			column:  3,
+			message: `unknown field "foo"`,
+		}},
+	}, {
+		name: "wrong types",
+		spec: `name: [sample]
+services:
+  name: web
+workers:
+- name: worker
+  instance_count: two
+  autoscaling:
+    min_instance_count: 1.5
+databases:
+- name: db
+  production: maybe
+`,
+		expected: []specIssue{{
+			path:    "name",
+			line:    1,
+			column:  7,
+			message: "expected a string, got a list",
+		}, {
+			path:    "services",
+			line:    3,
+			column:  3,
+			message: "expected a list, got an object",
+		}, {
+			path:    "workers[0].instance_count",
+			line:    6,
+			column:  19,
+			message: `expected an integer, got string "two"`,
+		}, {
+			path:    "workers[0].autoscaling.min_instance_count",
+			line:    8,
+			column:  25,
+			message: "expected an integer, got number 1.5",
+		}, {
+			path:    "databases[0].production",
+			line:    11,
+			column:  15,
+			message: `expected a boolean, got string "maybe"`,
+		}},
+	}, {
+		name: "invalid enums",
+		spec: `name: sample
+jobs:
+- name: migrate
+  kind: PREDEPLOY
+`,
+		expected: []specIssue{{
+			path:    "jobs[0].kind",
+			line:    4,
+			column:  9,
+			message: `invalid value "PREDEPLOY", expected one of UNSPECIFIED, PRE_DEPLOY, POST_DEPLOY, FAILED_DEPLOY`,
+		}},
+	}, {
+		name: "overlay may delete items",
+		spec: `services:
+- name: web
+  _delete: true
+`,
+		overlay: true,
+	}, {
+		name: "spec may not delete items",
+		spec: `services:
+- name: web
+  _delete: true
+`,
+		expected: []specIssue{{
+			path:    "services[0]._delete",
+			line:    3,
+			column:  3,
+			message: `unknown field "_delete"`,
+		}},
+	}, {
+		name: "anchors and merge keys",
+		spec: `x-defaults: &defaults
+  instance_count: 2
+name: sample
+services:
+- <<: *defaults
+  name: web
+`,
+		expected: []specIssue{{
+			path:    "x-defaults",
+			line:    1,
+			column:  1,
+			message: `unknown field "x-defaults"`,
+		}},
+	}, {
+		name: "invalid YAML",
+		spec: "name: [",
+		err:  true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			issues, err := validateSpecSchema([]byte(test.spec), test.overlay)
+			if test.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expected, issues)
+		})
+	}
+}
+
+func TestCreateSpecReportsSchemaIssues(t *testing.T) {
+	specFilePath := filepath.Join(t.TempDir(), "app.yaml")
+	require.NoError(t, os.WriteFile(specFilePath, []byte(`name: sample
+services:
+- name: web
+  instance_size_slg: basic-xxs
+`), 0644))
+
+	var actionLogs bytes.Buffer
+	d := &deployer{
+		action: gha.New(gha.WithWriter(&actionLogs)),
+		inputs: inputs{appSpecLocation: specFilePath},
+	}
+	_, err := d.createSpec(context.Background())
+	require.EqualError(t, err, "app spec doesn't match the schema, 1 issue(s) reported")
+	require.Equal(t, `::error col=3,file=`+specFilePath+`,line=4,title=Invalid app spec::services[0].instance_size_slg: unknown field "instance_size_slg", did you mean "instance_size_slug"?
+`, actionLogs.String())
+
+	// Inline specs are reported without a file.
+	actionLogs.Reset()
+	d.inputs.appSpec = `{"name": "sample", "services": "web"}`
+	_, err = d.createSpec(context.Background())
+	require.Error(t, err)
+	require.Equal(t, `::error col=32,line=1,title=Invalid app spec::services: expected a list, got string "web"
+`, actionLogs.String())
+
+	// Env vars spanning multiple lines shift the positions, so they're only part of the message.
+	t.Setenv("MULTILINE", "a\nb")
+	actionLogs.Reset()
+	d.inputs.appSpec = "name: sample\nenvs:\n- key: MULTILINE\n  value: \"${MULTILINE}\"\nservices: web"
+	_, err = d.createSpec(context.Background())
+	require.Error(t, err)
+	require.Equal(t, `::error title=Invalid app spec::services: expected a list, got string "web" (at line 6, column 11 of the rendered app_spec)
+`, actionLogs.String())
+
+	// So can Go templates.
+	actionLogs.Reset()
+	d.inputs = inputs{appSpecLocation: specFilePath, appSpecTemplating: specTemplatingGo}
+	_, err = d.createSpec(context.Background())
+	require.Error(t, err)
+	require.Equal(t, `::error title=Invalid app spec::services[0].instance_size_slg: unknown field "instance_size_slg", did you mean "instance_size_slug"? (at line 4, column 3 of the rendered `+specFilePath+`)
+`, actionLogs.String())
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/digitalocean/godo"
+)
+
+// smokeTestWaitDelay is how long to wait for the smoke test's output to be closed after the
+// command exited or was killed, for example if it spawned background processes.
+const smokeTestWaitDelay = 10 * time.Second
+
+// smokeTest runs the configured smoke test command against the live app. If it fails and
+// rolling back is enabled, the app is rolled back to the given previously active deployment.
+func (d *deployer) smokeTest(ctx context.Context, app *godo.App, deploymentID string, previous *godo.Deployment) error {
+	err := d.runSmokeTest(ctx, app, deploymentID)
+	if err == nil {
+		return nil
+	}
+	if !d.inputs.rollbackOnSmokeTestFailure {
+		return fmt.Errorf("smoke test failed: %w", err)
+	}
+
+	d.action.SetOutput("failed_deployment_id", deploymentID)
+	if previous == nil {
+		return fmt.Errorf("smoke test failed: %w, and there is no previous deployment to roll back to", err)
+	}
+	if rbErr := d.rollbackTo(ctx, app.GetID(), previous); rbErr != nil {
+		return fmt.Errorf("smoke test failed: %w, and rollback failed: %w", err, rbErr)
+	}
+	d.action.SetOutput("restored_deployment_id", previous.GetID())
+	return fmt.Errorf("smoke test failed: %w, rolled back to deployment %s", err, previous.GetID())
+}
+
+// runSmokeTest runs the configured smoke test command through the shell. The live URL, app ID
+// and deployment ID are passed to it as LIVE_URL, APP_ID and DEPLOYMENT_ID environment variables.
+// The action's inputs are not passed on, as they include the access token.
+func (d *deployer) runSmokeTest(ctx context.Context, app *godo.App, deploymentID string) error {
+	ctx, cancel := withTimeout(ctx, d.inputs.smokeTestTimeout)
+	defer cancel()
+
+	d.action.Infof("running smoke test against %s", app.GetLiveURL())
+	cmd := exec.CommandContext(ctx, "sh", "-c", d.inputs.smokeTestCommand)
+	cmd.Env = append(smokeTestEnviron(os.Environ()),
+		"LIVE_URL="+app.GetLiveURL(),
+		"APP_ID="+app.GetID(),
+		"DEPLOYMENT_ID="+deploymentID,
+	)
+	// Run the command in its own process group to also kill the processes it spawned on timeout.
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = smokeTestWaitDelay
+
+	// Forward the command's output line by line to be able to group it.
+	pr, pw := io.Pipe()
+	cmd.Stdout = pw
+	cmd.Stderr = pw
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r := bufio.NewReader(pr)
+		for {
+			line, err := r.ReadString('\n')
+			if line != "" {
+				d.action.Infof("%s", strings.TrimSuffix(line, "\n"))
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	d.action.Group("smoke test")
+	err := cmd.Run()
+	pw.Close()
+	<-done
+	d.action.EndGroup()
+
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("%w after %s", errTimeout, d.inputs.smokeTestTimeout)
+		}
+		return err
+	}
+	d.action.Infof("smoke test passed")
+	return nil
+}
+
+// smokeTestEnviron returns the given environment without the action's inputs, which GitHub
+// passes as INPUT_* environment variables.
+func smokeTestEnviron(environ []string) []string {
+	env := make([]string, 0, len(environ))
+	for _, kv := range environ {
+		if !strings.HasPrefix(kv, "INPUT_") {
+			env = append(env, kv)
+		}
+	}
+	return env
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/digitalocean/app_actions/utils"
+	"github.com/digitalocean/godo"
+	gha "github.com/sethvargo/go-githubactions"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSmokeTest(t *testing.T) {
+	ctx := context.Background()
+	appID := "app-id"
+	deploymentID := "deployment-id"
+	app := &godo.App{ID: appID, LiveURL: "https://example.com"}
+	previous := &godo.Deployment{ID: "previous-id"}
+	req := &utils.RollbackRequest{DeploymentID: previous.GetID(), SkipPin: true}
+	// Inputs are passed to the action as environment variables.
+	t.Setenv("INPUT_TOKEN", "secret")
+
+	tests := []struct {
+		name           string
+		inputs         inputs
+		previous       *godo.Deployment
+		appService     *mockedAppsService
+		rollbacks      *mockedRollbackService
+		expectedLogs   string
+		expectedOutput string
+		expectedErr    string
+	}{{
+		name: "passes",
+		inputs: inputs{
+			smokeTestCommand: `echo "$LIVE_URL $APP_ID $DEPLOYMENT_ID"; echo to stderr >&2`,
+		},
+		expectedLogs: `running smoke test against https://example.com
+::group::smoke test
+https://example.com app-id deployment-id
+to stderr
+::endgroup::
+smoke test passed
+`,
+	}, {
+		name: "doesn't pass on inputs",
+		inputs: inputs{
+			smokeTestCommand: `echo "token: ${INPUT_TOKEN:-unset}"`,
+		},
+		expectedLogs: `running smoke test against https://example.com
+::group::smoke test
+token: unset
+::endgroup::
+smoke test passed
+`,
+	}, {
+		name: "fails",
+		inputs: inputs{
+			smokeTestCommand: "echo broken; exit 3",
+		},
+		expectedLogs: `running smoke test against https://example.com
+::group::smoke test
+broken
+::endgroup::
+`,
+		expectedErr: "smoke test failed: exit status 3",
+	}, {
+		name: "times out",
+		inputs: inputs{
+			smokeTestCommand: "sleep 5",
+			smokeTestTimeout: 50 * time.Millisecond,
+		},
+		expectedLogs: `running smoke test against https://example.com
+::group::smoke test
+::endgroup::
+`,
+		expectedErr: "smoke test failed: timed out after 50ms",
+	}, {
+		name: "rolls back on failure",
+		inputs: inputs{
+			smokeTestCommand:           "exit 1",
+			rollbackOnSmokeTestFailure: true,
+		},
+		previous:   previous,
+		appService: appServiceWithDeployment(ctx, appID, "rollback-id", godo.DeploymentPhase_Active),
+		rollbacks: func() *mockedRollbackService {
+			rs := &mockedRollbackService{}
+			rs.On("ValidateRollback", ctx, appID, req).Return(&utils.RollbackValidation{Valid: true}, &godo.Response{}, nil)
+			rs.On("Rollback", ctx, appID, req).Return(&godo.Deployment{ID: "rollback-id"}, &godo.Response{}, nil)
+			return rs
+		}(),
+		expectedLogs: `running smoke test against https://example.com
+::group::smoke test
+::endgroup::
+rolling back to deployment previous-id
+wait for rollback to finish
+deployment is in phase: ACTIVE
+`,
+		expectedOutput: `failed_deployment_id<<_GitHubActionsFileCommandDelimeter_
+deployment-id
+_GitHubActionsFileCommandDelimeter_
+restored_deployment_id<<_GitHubActionsFileCommandDelimeter_
+previous-id
+_GitHubActionsFileCommandDelimeter_
+`,
+		expectedErr: "smoke test failed: exit status 1, rolled back to deployment previous-id",
+	}, {
+		name: "nothing to roll back to",
+		inputs: inputs{
+			smokeTestCommand:           "exit 1",
+			rollbackOnSmokeTestFailure: true,
+		},
+		expectedLogs: `running smoke test against https://example.com
+::group::smoke test
+::endgroup::
+`,
+		expectedOutput: `failed_deployment_id<<_GitHubActionsFileCommandDelimeter_
+deployment-id
+_GitHubActionsFileCommandDelimeter_
+`,
+		expectedErr: "smoke test failed: exit status 1, and there is no previous deployment to roll back to",
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var actionLogs bytes.Buffer
+			outputFilePath := t.TempDir() + "/output"
+			d := &deployer{
+				action: gha.New(gha.WithWriter(&actionLogs), gha.WithGetenv(func(k string) string {
+					if k == "GITHUB_OUTPUT" {
+						return outputFilePath
+					}
+					return ""
+				})),
+				apps:      test.appService,
+				rollbacks: test.rollbacks,
+				inputs:    test.inputs,
+			}
+
+			err := d.smokeTest(ctx, app, deploymentID, test.previous)
+			if test.expectedErr != "" {
+				require.EqualError(t, err, test.expectedErr)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, test.expectedLogs, actionLogs.String())
+
+			output, err := os.ReadFile(outputFilePath)
+			if test.expectedOutput == "" {
+				require.ErrorIs(t, err, os.ErrNotExist)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, test.expectedOutput, string(output))
+			}
+
+			if test.appService != nil {
+				test.appService.AssertExpectations(t)
+			}
+			if test.rollbacks != nil {
+				test.rollbacks.AssertExpectations(t)
+			}
+		})
+	}
+}
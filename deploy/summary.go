@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/digitalocean/godo"
+)
+
+// controlPanelURL is the base URL of App Platform's control panel.
+const controlPanelURL = "https://cloud.digitalocean.com/apps"
+
+// writeSummary renders a Markdown summary of the given app and deployment into the job summary.
+func (d *deployer) writeSummary(app *godo.App, dep *godo.Deployment) {
+	d.action.AddStepSummary(renderSummary(app, dep))
+}
+
+// renderSummary renders a Markdown summary of the given app and deployment.
+func renderSummary(app *godo.App, dep *godo.Deployment) string {
+	spec := dep.GetSpec()
+	if spec == nil {
+		spec = app.GetSpec()
+	}
+	appURL := fmt.Sprintf("%s/%s", controlPanelURL, app.GetID())
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "### App Platform deployment of `%s`\n\n", spec.GetName())
+	b.WriteString("| | |\n|---|---|\n")
+	fmt.Fprintf(&b, "| **App** | [%s](%s) (`%s`) |\n", spec.GetName(), appURL, app.GetID())
+	fmt.Fprintf(&b, "| **Live URL** | %s |\n", orDash(app.GetLiveURL()))
+	if dep.GetID() != "" {
+		fmt.Fprintf(&b, "| **Deployment** | [`%s`](%s/deployments/%s) |\n", dep.GetID(), appURL, dep.GetID())
+	}
+	fmt.Fprintf(&b, "| **Phase** | %s |\n", orDash(string(dep.GetPhase())))
+	fmt.Fprintf(&b, "| **Cause** | %s |\n", orDash(dep.GetCause()))
+
+	commits := deploymentCommits(dep)
+	var components strings.Builder
+	_ = spec.ForEachAppComponentSpec(func(c godo.AppComponentSpec) error {
+		image := "-"
+		if cc, ok := c.(godo.AppContainerComponentSpec); ok && cc.GetImage() != nil {
+			image = fmt.Sprintf("`%s`", formatImage(cc.GetImage()))
+		}
+		commit := "-"
+		if hash := commits[c.GetName()]; hash != "" {
+			commit = fmt.Sprintf("`%s`", hash[:min(len(hash), 7)])
+		}
+		fmt.Fprintf(&components, "| %s | %s | %s | %s |\n", c.GetName(), strings.ToLower(string(c.GetType())), image, commit)
+		return nil
+	})
+	if components.Len() > 0 {
+		b.WriteString("\n#### Components\n\n| Component | Type | Image | Commit |\n|---|---|---|---|\n")
+		b.WriteString(components.String())
+	}
+
+	if steps := dep.GetProgress().GetSteps(); len(steps) > 0 {
+		b.WriteString("\n#### Timings\n\n| Step | Status | Duration |\n|---|---|---|\n")
+		for _, step := range steps {
+			fmt.Fprintf(&b, "| %s | %s | %s |\n", step.Name, step.Status, orDash(formatStepDuration(step)))
+		}
+	}
+	return b.String()
+}
+
+// deploymentCommits returns the source commit of every component of the given deployment.
+func deploymentCommits(dep *godo.Deployment) map[string]string {
+	commits := make(map[string]string)
+	for _, c := range dep.GetServices() {
+		commits[c.Name] = c.SourceCommitHash
+	}
+	for _, c := range dep.GetStaticSites() {
+		commits[c.Name] = c.SourceCommitHash
+	}
+	for _, c := range dep.GetWorkers() {
+		commits[c.Name] = c.SourceCommitHash
+	}
+	for _, c := range dep.GetJobs() {
+		commits[c.Name] = c.SourceCommitHash
+	}
+	for _, c := range dep.GetFunctions() {
+		commits[c.Name] = c.SourceCommitHash
+	}
+	return commits
+}
+
+// formatStepDuration returns how long the given step took or an empty string if it didn't finish.
+func formatStepDuration(step *godo.DeploymentProgressStep) string {
+	if step.StartedAt.IsZero() || step.EndedAt.IsZero() {
+		return ""
+	}
+	return step.EndedAt.Sub(step.StartedAt).Round(time.Second).String()
+}
+
+// orDash returns the given string or a dash if it's empty.
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/digitalocean/godo"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderSummary(t *testing.T) {
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	app := &godo.App{
+		ID:      "app-id",
+		LiveURL: "https://foo.ondigitalocean.app",
+	}
+	dep := &godo.Deployment{
+		ID:    "deployment-id",
+		Phase: godo.DeploymentPhase_Active,
+		Cause: "app spec updated",
+		Spec: &godo.AppSpec{
+			Name: "foo",
+			Services: []*godo.AppServiceSpec{{
+				Name:   "web",
+				GitHub: &godo.GitHubSourceSpec{Repo: "foo/bar", Branch: "main"},
+			}, {
+				Name: "api",
+				Image: &godo.ImageSourceSpec{
+					RegistryType: godo.ImageSourceSpecRegistryType_Ghcr,
+					Registry:     "foo",
+					Repository:   "api",
+					Tag:          "v1",
+				},
+			}},
+		},
+		Services: []*godo.DeploymentService{{Name: "web", SourceCommitHash: "0123456789abcdef"}},
+		Progress: &godo.DeploymentProgress{
+			Steps: []*godo.DeploymentProgressStep{{
+				Name:      "build",
+				Status:    godo.DeploymentProgressStepStatus_Success,
+				StartedAt: start,
+				EndedAt:   start.Add(95 * time.Second),
+			}, {
+				Name:      "deploy",
+				Status:    godo.DeploymentProgressStepStatus_Running,
+				StartedAt: start.Add(95 * time.Second),
+			}},
+		},
+	}
+
+	require.Equal(t, "### App Platform deployment of `foo`"+`
+
+| | |
+|---|---|
+| **App** | [foo](https://cloud.digitalocean.com/apps/app-id) (`+"`app-id`"+`) |
+| **Live URL** | https://foo.ondigitalocean.app |
+| **Deployment** | [`+"`deployment-id`"+`](https://cloud.digitalocean.com/apps/app-id/deployments/deployment-id) |
+| **Phase** | ACTIVE |
+| **Cause** | app spec updated |
+
+#### Components
+
+| Component | Type | Image | Commit |
+|---|---|---|---|
+| web | service | - | `+"`0123456`"+` |
+| api | service | `+"`GHCR/foo/api:v1`"+` | - |
+
+#### Timings
+
+| Step | Status | Duration |
+|---|---|---|
+| build | SUCCESS | 1m35s |
+| deploy | RUNNING | - |
+`, renderSummary(app, dep))
+}
+
+func TestRenderSummaryWithoutDeployment(t *testing.T) {
+	app := &godo.App{ID: "app-id", Spec: &godo.AppSpec{Name: "foo"}}
+
+	require.Equal(t, "### App Platform deployment of `foo`"+`
+
+| | |
+|---|---|
+| **App** | [foo](https://cloud.digitalocean.com/apps/app-id) (`+"`app-id`"+`) |
+| **Live URL** | - |
+| **Phase** | - |
+| **Cause** | - |
+`, renderSummary(app, nil))
+}
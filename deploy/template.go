@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/template"
+
+	gha "github.com/sethvargo/go-githubactions"
+)
+
+const (
+	// specTemplatingEnv expands environment variables in the app spec.
+	specTemplatingEnv = "env"
+	// specTemplatingGo renders the app spec as a Go text/template.
+	specTemplatingGo = "go"
+)
+
+// specTemplatings are the valid values of the app_spec_templating input.
+var specTemplatings = []string{specTemplatingEnv, specTemplatingGo}
+
+// expandSpec expands the given raw app spec according to the configured templating mode.
+func (d *deployer) expandSpec(name string, content []byte) ([]byte, error) {
+	switch d.inputs.appSpecTemplating {
+	case specTemplatingGo:
+		ghCtx, err := d.action.Context()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get GitHub context: %w", err)
+		}
+		return renderSpecTemplate(name, content, ghCtx)
+	default:
+		if d.inputs.strictEnvExpansion {
+			expanded, err := expandEnvStrict(string(content))
+			if err != nil {
+				return nil, err
+			}
+			return []byte(expanded), nil
+		}
+		return []byte(os.ExpandEnv(string(content))), nil
+	}
+}
+
+// expandEnvStrict expands environment variables like os.ExpandEnv, but fails with a list of all
+// referenced variables that are unset or empty. Defaults can be given via ${VAR:-default} and a
+// literal $ can be written as $$, for example $${VAR}.
+func expandEnvStrict(s string) (string, error) {
+	var missing []string
+	expanded := os.Expand(s, func(name string) string {
+		if name == "$" {
+			return "$"
+		}
+		name, def, hasDefault := strings.Cut(name, ":-")
+		if val := os.Getenv(name); val != "" {
+			return val
+		}
+		if hasDefault {
+			return def
+		}
+		if !slices.Contains(missing, name) {
+			missing = append(missing, name)
+		}
+		return ""
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("environment variables are unset or empty: %s", strings.Join(missing, ", "))
+	}
+	return expanded, nil
+}
+
+// specTemplateData is the data app spec templates are executed with.
+type specTemplateData struct {
+	GitHub specTemplateGitHub
+}
+
+// specTemplateGitHub are the fields of the GitHub context available to app spec templates.
+type specTemplateGitHub struct {
+	SHA        string
+	Ref        string
+	RefName    string
+	HeadRef    string
+	BaseRef    string
+	Repository string
+	EventName  string
+	// PRNumber is the number of the pull request that triggered the workflow, if any.
+	PRNumber int
+}
+
+// renderSpecTemplate renders the given app spec as a Go text/template. Besides the GitHub context
+// as data, the template has access to functions to look up environment variables and to encode
+// values.
+func renderSpecTemplate(name string, content []byte, ghCtx *gha.GitHubContext) ([]byte, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Funcs(specTemplateFuncs).Parse(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse app spec template: %w", err)
+	}
+
+	data := specTemplateData{GitHub: specTemplateGitHub{
+		SHA:        ghCtx.SHA,
+		Ref:        ghCtx.Ref,
+		RefName:    ghCtx.RefName,
+		HeadRef:    ghCtx.HeadRef,
+		BaseRef:    ghCtx.BaseRef,
+		Repository: ghCtx.Repository,
+		EventName:  ghCtx.EventName,
+		PRNumber:   pullRequestNumber(ghCtx),
+	}}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to render app spec template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// specTemplateFuncs are the functions available to app spec templates.
+var specTemplateFuncs = template.FuncMap{
+	// env returns the value of the given environment variable or the optional default if it's
+	// unset or empty.
+	"env": func(key string, def ...string) (string, error) {
+		if len(def) > 1 {
+			return "", fmt.Errorf("env takes at most one default, got %d", len(def))
+		}
+		if val := os.Getenv(key); val != "" || len(def) == 0 {
+			return val, nil
+		}
+		return def[0], nil
+	},
+	// required returns the value of the given environment variable and fails if it's unset or
+	// empty.
+	"required": func(key string) (string, error) {
+		val := os.Getenv(key)
+		if val == "" {
+			return "", fmt.Errorf("environment variable %q is required but not set", key)
+		}
+		return val, nil
+	},
+	"b64enc": func(s string) string {
+		return base64.StdEncoding.EncodeToString([]byte(s))
+	},
+	"b64dec": func(s string) (string, error) {
+		bs, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return "", fmt.Errorf("failed to decode base64: %w", err)
+		}
+		return string(bs), nil
+	},
+	"toJson": func(v any) (string, error) {
+		bs, err := json.Marshal(v)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		return string(bs), nil
+	},
+	"fromJson": func(s string) (any, error) {
+		var v any
+		if err := json.Unmarshal([]byte(s), &v); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal JSON: %w", err)
+		}
+		return v, nil
+	},
+}
+
+// pullRequestNumber returns the number of the pull request that triggered the workflow or zero if
+// it wasn't triggered by a pull request.
+func pullRequestNumber(ghCtx *gha.GitHubContext) int {
+	pr, ok := ghCtx.Event["pull_request"].(map[string]any)
+	if !ok {
+		return 0
+	}
+	number, ok := pr["number"].(float64)
+	if !ok {
+		return 0
+	}
+	return int(number)
+}
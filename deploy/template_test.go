@@ -0,0 +1,174 @@
+package main
+
+import (
+	"testing"
+
+	gha "github.com/sethvargo/go-githubactions"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderSpecTemplate(t *testing.T) {
+	t.Setenv("IMAGE_TAG", "v1")
+	t.Setenv("EMPTY", "")
+	t.Setenv("DB_URL", "postgres://db")
+	t.Setenv("CONFIG", `{"replicas":3}`)
+
+	ghCtx := &gha.GitHubContext{
+		SHA:     "abcdef1234567890",
+		Ref:     "refs/pull/42/merge",
+		RefName: "42/merge",
+		Event: map[string]any{
+			"pull_request": map[string]any{"number": float64(42)},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		expected string
+		err      bool
+	}{{
+		name:     "env",
+		template: `tag: {{ env "IMAGE_TAG" }}`,
+		expected: `tag: v1`,
+	}, {
+		name:     "env with default",
+		template: `tag: {{ env "UNSET_VAR" "latest" }}, {{ env "EMPTY" "latest" }}, {{ env "IMAGE_TAG" "latest" }}`,
+		expected: `tag: latest, latest, v1`,
+	}, {
+		name:     "env without default",
+		template: `tag: "{{ env "UNSET_VAR" }}"`,
+		expected: `tag: ""`,
+	}, {
+		name:     "env with too many defaults",
+		template: `{{ env "UNSET_VAR" "a" "b" }}`,
+		err:      true,
+	}, {
+		name:     "required",
+		template: `value: {{ required "DB_URL" }}`,
+		expected: `value: postgres://db`,
+	}, {
+		name:     "required but unset",
+		template: `value: {{ required "UNSET_VAR" }}`,
+		err:      true,
+	}, {
+		name:     "github context",
+		template: `{{ .GitHub.SHA }} {{ .GitHub.Ref }} {{ .GitHub.RefName }} {{ .GitHub.PRNumber }}`,
+		expected: `abcdef1234567890 refs/pull/42/merge 42/merge 42`,
+	}, {
+		name: "conditionals and loops",
+		template: `name: sample{{ if .GitHub.PRNumber }}-pr-{{ .GitHub.PRNumber }}{{ end }}
+services:
+{{- range $name := fromJson "[\"web\",\"api\"]" }}
+- name: {{ $name }}
+{{- end }}`,
+		expected: `name: sample-pr-42
+services:
+- name: web
+- name: api`,
+	}, {
+		name:     "base64",
+		template: `{{ b64enc "secret" }} {{ b64dec "c2VjcmV0" }}`,
+		expected: `c2VjcmV0 secret`,
+	}, {
+		name:     "invalid base64",
+		template: `{{ b64dec "not base64!" }}`,
+		err:      true,
+	}, {
+		name:     "json",
+		template: `{{ $cfg := fromJson (env "CONFIG") }}replicas: {{ $cfg.replicas }}, raw: {{ toJson $cfg }}`,
+		expected: `replicas: 3, raw: {"replicas":3}`,
+	}, {
+		name:     "unknown field",
+		template: `{{ .GitHub.Unknown }}`,
+		err:      true,
+	}, {
+		name:     "invalid template",
+		template: `{{ if }}`,
+		err:      true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := renderSpecTemplate("app.yaml", []byte(test.template), ghCtx)
+			if test.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expected, string(got))
+		})
+	}
+}
+
+func TestPullRequestNumber(t *testing.T) {
+	require.Equal(t, 42, pullRequestNumber(&gha.GitHubContext{Event: map[string]any{
+		"pull_request": map[string]any{"number": float64(42)},
+	}}))
+	require.Equal(t, 0, pullRequestNumber(&gha.GitHubContext{Event: map[string]any{"ref": "refs/heads/main"}}))
+	require.Equal(t, 0, pullRequestNumber(&gha.GitHubContext{}))
+}
+
+func TestExpandSpec(t *testing.T) {
+	t.Setenv("IMAGE_TAG", "v1")
+	a := gha.New(gha.WithGetenv(func(k string) string {
+		if k == "GITHUB_SHA" {
+			return "abcdef"
+		}
+		return ""
+	}))
+
+	d := &deployer{action: a, inputs: inputs{appSpecTemplating: specTemplatingEnv}}
+	got, err := d.expandSpec("app.yaml", []byte(`tag: ${IMAGE_TAG}`))
+	require.NoError(t, err)
+	require.Equal(t, `tag: v1`, string(got))
+
+	d = &deployer{action: a, inputs: inputs{appSpecTemplating: specTemplatingEnv, strictEnvExpansion: true}}
+	_, err = d.expandSpec("app.yaml", []byte(`tag: ${IMAGE_TAG}, digest: ${UNSET_VAR}`))
+	require.EqualError(t, err, "environment variables are unset or empty: UNSET_VAR")
+
+	d = &deployer{action: a, inputs: inputs{appSpecTemplating: specTemplatingGo}}
+	got, err = d.expandSpec("app.yaml", []byte(`tag: ${IMAGE_TAG}-{{ .GitHub.SHA }}-{{ env "IMAGE_TAG" }}`))
+	require.NoError(t, err)
+	require.Equal(t, `tag: ${IMAGE_TAG}-abcdef-v1`, string(got))
+}
+
+func TestExpandEnvStrict(t *testing.T) {
+	t.Setenv("IMAGE_TAG", "v1")
+	t.Setenv("EMPTY", "")
+
+	tests := []struct {
+		name        string
+		input       string
+		expected    string
+		expectedErr string
+	}{{
+		name:     "set",
+		input:    `tag: ${IMAGE_TAG}, $IMAGE_TAG`,
+		expected: `tag: v1, v1`,
+	}, {
+		name:     "defaults",
+		input:    `tag: ${UNSET_VAR:-latest}, ${EMPTY:-latest}, ${IMAGE_TAG:-latest}, ${UNSET_VAR:-}`,
+		expected: `tag: latest, latest, v1, `,
+	}, {
+		name:     "escaped",
+		input:    `command: echo $${HOME} $$PATH costs 5$`,
+		expected: `command: echo ${HOME} $PATH costs 5$`,
+	}, {
+		name:        "unset and empty",
+		input:       `digest: ${DIGEST_TYPO}, tag: ${EMPTY}, again: $DIGEST_TYPO, other: ${OTHER}`,
+		expectedErr: "environment variables are unset or empty: DIGEST_TYPO, EMPTY, OTHER",
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := expandEnvStrict(test.input)
+			if test.expectedErr != "" {
+				require.EqualError(t, err, test.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expected, got)
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/digitalocean/godo"
+)
+
+// validateSpec validates the given spec against App Platform's propose endpoint. If appID is
+// non-empty, the spec is validated as an update to the respective app.
+func (d *deployer) validateSpec(ctx context.Context, spec *godo.AppSpec, appID string) (*godo.AppProposeResponse, error) {
+	res, _, err := d.apps.Propose(ctx, &godo.AppProposeRequest{Spec: spec, AppID: appID})
+	if err != nil {
+		// Surface the validation errors themselves rather than the full HTTP error.
+		var errResp *godo.ErrorResponse
+		if errors.As(err, &errResp) && errResp.Message != "" {
+			return nil, fmt.Errorf("app spec is invalid: %s", errResp.Message)
+		}
+		return nil, fmt.Errorf("failed to propose app spec: %w", err)
+	}
+
+	d.action.Infof("app spec is valid, estimated monthly cost: $%.2f", res.AppCost)
+	if res.AppTierUpgradeCost > 0 {
+		d.action.Infof("upgrading to the next tier would cost $%.2f per month", res.AppTierUpgradeCost)
+	}
+	if res.AppTierDowngradeCost > 0 {
+		d.action.Infof("downgrading to the previous tier would cost $%.2f per month", res.AppTierDowngradeCost)
+	}
+	return res, nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/digitalocean/godo"
+	gha "github.com/sethvargo/go-githubactions"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateSpec(t *testing.T) {
+	ctx := context.Background()
+	spec := &godo.AppSpec{Name: "foo"}
+
+	tests := []struct {
+		name         string
+		appID        string
+		proposeResp  *godo.AppProposeResponse
+		proposeErr   error
+		expectedLogs string
+		expectedErr  string
+	}{{
+		name:         "valid new app",
+		proposeResp:  &godo.AppProposeResponse{AppCost: 12},
+		expectedLogs: "app spec is valid, estimated monthly cost: $12.00\n",
+	}, {
+		name:        "valid update with tier hints",
+		appID:       "app-id",
+		proposeResp: &godo.AppProposeResponse{AppCost: 12, AppTierUpgradeCost: 24, AppTierDowngradeCost: 5},
+		expectedLogs: `app spec is valid, estimated monthly cost: $12.00
+upgrading to the next tier would cost $24.00 per month
+downgrading to the previous tier would cost $5.00 per month
+`,
+	}, {
+		name:        "invalid spec",
+		proposeResp: &godo.AppProposeResponse{},
+		proposeErr: &godo.ErrorResponse{
+			Response: &http.Response{StatusCode: http.StatusBadRequest, Request: &http.Request{}},
+			Message:  "error validating app spec field \"services.instance_size_slug\"",
+		},
+		expectedErr: "app spec is invalid: error validating app spec field \"services.instance_size_slug\"",
+	}, {
+		name:        "generic error",
+		proposeResp: &godo.AppProposeResponse{},
+		proposeErr:  errors.New("an error"),
+		expectedErr: "failed to propose app spec: an error",
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			as := &mockedAppsService{}
+			as.On("Propose", ctx, &godo.AppProposeRequest{Spec: spec, AppID: test.appID}).Return(test.proposeResp, &godo.Response{}, test.proposeErr)
+
+			var actionLogs bytes.Buffer
+			d := &deployer{
+				action: gha.New(gha.WithWriter(&actionLogs)),
+				apps:   as,
+			}
+
+			_, err := d.validateSpec(ctx, spec, test.appID)
+			if test.expectedErr != "" {
+				require.EqualError(t, err, test.expectedErr)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, test.expectedLogs, actionLogs.String())
+			as.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// websocketGUID is the GUID used to compute the Sec-WebSocket-Accept header, see RFC 6455.
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// maxWebsocketMessageSize caps the size of a single websocket message to not exhaust memory on a
+// misbehaving server.
+const maxWebsocketMessageSize = 16 << 20
+
+// Websocket opcodes, see RFC 6455.
+const (
+	wsOpContinuation = 0x0
+	wsOpText         = 0x1
+	wsOpBinary       = 0x2
+	wsOpClose        = 0x8
+	wsOpPing         = 0x9
+	wsOpPong         = 0xa
+)
+
+// logMessage is a message of the websocket serving live logs.
+type logMessage struct {
+	Data string `json:"data"`
+}
+
+// streamLogs calls fn for every line of the logs served by the given live URL until the stream
+// ends. App Platform serves live logs via a websocket whose messages carry chunks of the logs as
+// JSON, so the URL is connected to as such.
+func (d *deployer) streamLogs(ctx context.Context, liveURL string, fn func(line string)) error {
+	conn, err := dialWebsocket(ctx, d.httpClient, liveURL)
+	if err != nil {
+		return fmt.Errorf("failed to get live logs: %w", err)
+	}
+	defer conn.Close()
+
+	// A chunk doesn't necessarily end with a full line, so keep the rest for the next one.
+	var partial string
+	for {
+		msg, err := conn.readMessage()
+		if errors.Is(err, io.EOF) {
+			if partial != "" {
+				fn(partial)
+			}
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read live logs: %w", err)
+		}
+
+		var m logMessage
+		if err := json.Unmarshal(msg, &m); err != nil {
+			return fmt.Errorf("failed to decode live logs: %w", err)
+		}
+		lines := strings.Split(partial+m.Data, "\n")
+		partial = lines[len(lines)-1]
+		for _, line := range lines[:len(lines)-1] {
+			fn(line)
+		}
+	}
+}
+
+// websocketConn is a client connection to a websocket. It only supports what's needed to read
+// messages from a server.
+type websocketConn struct {
+	r    *bufio.Reader
+	w    io.Writer
+	body io.Closer
+	stop func() bool
+}
+
+// dialWebsocket opens a websocket connection to the given URL using the given HTTP client. The
+// connection is closed once the given context is done.
+func dialWebsocket(ctx context.Context, client *http.Client, rawURL string) (*websocketConn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	// The handshake is a plain HTTP request.
+	switch u.Scheme {
+	case "ws":
+		u.Scheme = "http"
+	case "wss":
+		u.Scheme = "https"
+	}
+
+	keyBytes := make([]byte, 16)
+	if _, err := rand.Read(keyBytes); err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+	key := base64.StdEncoding.EncodeToString(keyBytes)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	if token := u.Query().Get("token"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		// Don't surface the body, it's not part of the logs.
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	rwc, ok := resp.Body.(io.ReadWriteCloser)
+	if !ok {
+		resp.Body.Close()
+		return nil, errors.New("connection doesn't support websockets")
+	}
+	if resp.Header.Get("Sec-WebSocket-Accept") != websocketAccept(key) {
+		rwc.Close()
+		return nil, errors.New("invalid Sec-WebSocket-Accept header")
+	}
+
+	return &websocketConn{
+		r:    bufio.NewReader(rwc),
+		w:    rwc,
+		body: rwc,
+		stop: context.AfterFunc(ctx, func() { rwc.Close() }),
+	}, nil
+}
+
+// websocketAccept returns the expected Sec-WebSocket-Accept header for the given key.
+func websocketAccept(key string) string {
+	h := sha1.Sum([]byte(key + websocketGUID))
+	return base64.StdEncoding.EncodeToString(h[:])
+}
+
+// Close closes the connection.
+func (c *websocketConn) Close() error {
+	c.stop()
+	return c.body.Close()
+}
+
+// readMessage reads the next data message, answering pings along the way. It returns io.EOF once
+// the server closed the connection.
+func (c *websocketConn) readMessage() ([]byte, error) {
+	var msg []byte
+	for {
+		fin, op, payload, err := c.readFrame()
+		if err != nil {
+			return nil, err
+		}
+
+		switch op {
+		case wsOpClose:
+			return nil, io.EOF
+		case wsOpPing:
+			if err := c.writeFrame(wsOpPong, payload); err != nil {
+				return nil, err
+			}
+			continue
+		case wsOpPong:
+			continue
+		case wsOpText, wsOpBinary, wsOpContinuation:
+		default:
+			return nil, fmt.Errorf("unknown opcode %d", op)
+		}
+
+		if len(msg)+len(payload) > maxWebsocketMessageSize {
+			return nil, errors.New("message too large")
+		}
+		msg = append(msg, payload...)
+		if fin {
+			return msg, nil
+		}
+	}
+}
+
+// readFrame reads a single frame.
+func (c *websocketConn) readFrame() (bool, byte, []byte, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(c.r, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin := header[0]&0x80 != 0
+	op := header[0] & 0x0f
+	masked := header[1]&0x80 != 0
+
+	length := uint64(header[1] & 0x7f)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.r, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.r, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	if length > maxWebsocketMessageSize {
+		return false, 0, nil, errors.New("frame too large")
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.r, mask[:]); err != nil {
+			return false, 0, nil, err
+		}
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.r, payload); err != nil {
+		return false, 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return fin, op, payload, nil
+}
+
+// writeFrame writes a single, final control frame. Frames sent by clients must be masked.
+func (c *websocketConn) writeFrame(op byte, payload []byte) error {
+	if len(payload) > 125 {
+		return errors.New("control frame too large")
+	}
+	frame := make([]byte, 0, 6+len(payload))
+	frame = append(frame, 0x80|op, 0x80|byte(len(payload)))
+
+	var mask [4]byte
+	if _, err := rand.Read(mask[:]); err != nil {
+		return err
+	}
+	frame = append(frame, mask[:]...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+	_, err := c.w.Write(frame)
+	return err
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamLogs(t *testing.T) {
+	tests := []struct {
+		name     string
+		handle   func(t *testing.T, rw *bufio.ReadWriter)
+		expected []string
+		err      string
+	}{{
+		name: "splits messages into lines",
+		handle: func(t *testing.T, rw *bufio.ReadWriter) {
+			writeLogFrame(t, rw, "line 1\nli")
+			writeLogFrame(t, rw, "ne 2\n")
+			writeLogFrame(t, rw, "line 3")
+			writeTestFrame(t, rw, wsOpClose, true, nil)
+		},
+		expected: []string{"line 1", "line 2", "line 3"},
+	}, {
+		name: "fragmented messages",
+		handle: func(t *testing.T, rw *bufio.ReadWriter) {
+			writeTestFrame(t, rw, wsOpText, false, []byte(`{"data":"fragmented `))
+			writeTestFrame(t, rw, wsOpContinuation, true, []byte(`line\n"}`))
+			writeTestFrame(t, rw, wsOpClose, true, nil)
+		},
+		expected: []string{"fragmented line"},
+	}, {
+		name: "answers pings",
+		handle: func(t *testing.T, rw *bufio.ReadWriter) {
+			writeTestFrame(t, rw, wsOpPing, true, []byte("ping"))
+			c := &websocketConn{r: rw.Reader}
+			_, op, payload, err := c.readFrame()
+			require.NoError(t, err)
+			require.Equal(t, byte(wsOpPong), op)
+			require.Equal(t, "ping", string(payload))
+
+			writeLogFrame(t, rw, "after ping\n")
+			writeTestFrame(t, rw, wsOpClose, true, nil)
+		},
+		expected: []string{"after ping"},
+	}, {
+		name: "long messages",
+		handle: func(t *testing.T, rw *bufio.ReadWriter) {
+			writeLogFrame(t, rw, strings.Repeat("a", 70000)+"\n")
+			writeTestFrame(t, rw, wsOpClose, true, nil)
+		},
+		expected: []string{strings.Repeat("a", 70000)},
+	}, {
+		name: "invalid messages",
+		handle: func(t *testing.T, rw *bufio.ReadWriter) {
+			writeTestFrame(t, rw, wsOpText, true, []byte("not json"))
+		},
+		err: "failed to decode live logs: invalid character 'o' in literal null (expecting 'u')",
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			srv := newWebsocketServer(t, func(rw *bufio.ReadWriter) { test.handle(t, rw) })
+			d := &deployer{httpClient: srv.Client()}
+
+			var lines []string
+			// App Platform hands out live URLs with either scheme.
+			err := d.streamLogs(context.Background(), strings.Replace(srv.URL, "http", "ws", 1), func(line string) {
+				lines = append(lines, line)
+			})
+			if test.err != "" {
+				require.EqualError(t, err, test.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, test.expected, lines)
+		})
+	}
+}
+
+func TestStreamLogsRejectsNonWebsocketResponses(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "panic: not a log line", http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+	d := &deployer{httpClient: srv.Client()}
+
+	var lines []string
+	err := d.streamLogs(context.Background(), srv.URL, func(line string) {
+		lines = append(lines, line)
+	})
+	require.EqualError(t, err, "failed to get live logs: unexpected status 401 Unauthorized")
+	require.Empty(t, lines)
+}
+
+// newWebsocketServer starts a server that accepts websocket connections and hands them to the
+// given function. The connection is closed once the function returns.
+func newWebsocketServer(t *testing.T, handle func(rw *bufio.ReadWriter)) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Upgrade") != "websocket" {
+			http.Error(w, "expected a websocket", http.StatusBadRequest)
+			return
+		}
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Errorf("failed to hijack connection: %v", err)
+			return
+		}
+		defer conn.Close()
+
+		_, _ = io.WriteString(rw, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n")
+		_, _ = io.WriteString(rw, "Sec-WebSocket-Accept: "+websocketAccept(r.Header.Get("Sec-WebSocket-Key"))+"\r\n\r\n")
+		_ = rw.Flush()
+		handle(rw)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// newLogServer starts a websocket server that sends each chunk of logs received from the given
+// channel as a message. The connection is closed once the channel is closed or the client went
+// away.
+func newLogServer(t *testing.T, chunks <-chan string) *httptest.Server {
+	return newWebsocketServer(t, func(rw *bufio.ReadWriter) {
+		gone := make(chan struct{})
+		go func() {
+			defer close(gone)
+			_, _ = io.Copy(io.Discard, rw)
+		}()
+		for {
+			select {
+			case chunk, ok := <-chunks:
+				if !ok {
+					writeTestFrame(t, rw, wsOpClose, true, nil)
+					return
+				}
+				writeLogFrame(t, rw, chunk)
+			case <-gone:
+				return
+			}
+		}
+	})
+}
+
+// logChunks returns a closed channel holding the given chunks of logs.
+func logChunks(chunks ...string) <-chan string {
+	ch := make(chan string, len(chunks))
+	for _, chunk := range chunks {
+		ch <- chunk
+	}
+	close(ch)
+	return ch
+}
+
+// writeLogFrame writes the given chunk of logs as a message like App Platform does.
+func writeLogFrame(t *testing.T, rw *bufio.ReadWriter, data string) {
+	payload, err := json.Marshal(logMessage{Data: data})
+	require.NoError(t, err)
+	writeTestFrame(t, rw, wsOpText, true, payload)
+}
+
+// writeTestFrame writes an unmasked frame like a server does.
+func writeTestFrame(t *testing.T, rw *bufio.ReadWriter, op byte, fin bool, payload []byte) {
+	b0 := op
+	if fin {
+		b0 |= 0x80
+	}
+	frame := []byte{b0}
+	switch {
+	case len(payload) < 126:
+		frame = append(frame, byte(len(payload)))
+	case len(payload) <= 0xffff:
+		frame = append(frame, 126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(len(payload)))
+	default:
+		frame = append(frame, 127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(len(payload)))
+	}
+	frame = append(frame, payload...)
+	// Writes fail if the client went away already, which some tests provoke on purpose.
+	if _, err := rw.Write(frame); err == nil {
+		_ = rw.Flush()
+	}
+}
@@ -46,3 +46,8 @@ func (m *mockedAppsService) List(ctx context.Context, opt *godo.ListOptions) ([]
 	args := m.Called(ctx, opt)
 	return args.Get(0).([]*godo.App), args.Get(1).(*godo.Response), args.Error(2)
 }
+
+func (m *mockedAppsService) GetDeployment(ctx context.Context, appID string, deploymentID string) (*godo.Deployment, *godo.Response, error) {
+	args := m.Called(ctx, appID, deploymentID)
+	return args.Get(0).(*godo.Deployment), args.Get(1).(*godo.Response), args.Error(2)
+}
@@ -0,0 +1,72 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/digitalocean/godo"
+)
+
+// DeploymentService provides access to App Platform's deployment APIs that are not covered by godo.
+type DeploymentService interface {
+	CancelDeployment(ctx context.Context, appID, deploymentID string) (*godo.Deployment, *godo.Response, error)
+}
+
+// deploymentRoot is the API's envelope around a single deployment.
+type deploymentRoot struct {
+	Deployment *godo.Deployment `json:"deployment"`
+}
+
+// NewDeploymentService returns a DeploymentService using the given client.
+func NewDeploymentService(client *godo.Client) DeploymentService {
+	return &deploymentService{client: client}
+}
+
+// deploymentService implements DeploymentService on top of godo's client.
+type deploymentService struct {
+	client *godo.Client
+}
+
+// CancelDeployment cancels the given deployment.
+func (s *deploymentService) CancelDeployment(ctx context.Context, appID, deploymentID string) (*godo.Deployment, *godo.Response, error) {
+	path := fmt.Sprintf("/v2/apps/%s/deployments/%s/cancel", appID, deploymentID)
+	r, err := s.client.NewRequest(ctx, http.MethodPost, path, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	root := new(deploymentRoot)
+	resp, err := s.client.Do(ctx, r, root)
+	if err != nil {
+		return nil, resp, err
+	}
+	return root.Deployment, resp, nil
+}
+
+// WaitForDeploymentTerminal waits for the given deployment to be in a terminal phase. The given
+// callback is called with every observed state of the deployment.
+func WaitForDeploymentTerminal(ctx context.Context, ap godo.AppsService, appID, deploymentID string, onUpdate func(*godo.Deployment)) (*godo.Deployment, error) {
+	var dep *godo.Deployment
+	if err := Poll(ctx, DefaultBackoff, func() (bool, error) {
+		var err error
+		dep, _, err = ap.GetDeployment(ctx, appID, deploymentID)
+		if err != nil {
+			return false, fmt.Errorf("failed to get deployment: %w", err)
+		}
+
+		onUpdate(dep)
+		return IsInTerminalPhase(dep), nil
+	}); err != nil {
+		return nil, err
+	}
+	return dep, nil
+}
+
+// IsInTerminalPhase returns whether or not the given deployment is in a terminal phase.
+func IsInTerminalPhase(d *godo.Deployment) bool {
+	switch d.GetPhase() {
+	case godo.DeploymentPhase_Active, godo.DeploymentPhase_Error, godo.DeploymentPhase_Canceled, godo.DeploymentPhase_Superseded:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/digitalocean/godo"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWaitForDeploymentTerminal(t *testing.T) {
+	ctx := context.Background()
+
+	as := &mockedAppsService{}
+	as.On("GetDeployment", ctx, "app-id", "deployment-id").Return(&godo.Deployment{ID: "deployment-id", Phase: godo.DeploymentPhase_Active}, &godo.Response{}, nil).Once()
+
+	var updates []godo.DeploymentPhase
+	dep, err := WaitForDeploymentTerminal(ctx, as, "app-id", "deployment-id", func(d *godo.Deployment) {
+		updates = append(updates, d.GetPhase())
+	})
+	require.NoError(t, err)
+	require.Equal(t, "deployment-id", dep.ID)
+	require.Equal(t, []godo.DeploymentPhase{godo.DeploymentPhase_Active}, updates)
+
+	as.On("GetDeployment", ctx, "app-id", "deployment-id").Return(&godo.Deployment{}, &godo.Response{}, errors.New("an error")).Once()
+	_, err = WaitForDeploymentTerminal(ctx, as, "app-id", "deployment-id", func(*godo.Deployment) {})
+	require.Error(t, err)
+
+	as.AssertExpectations(t)
+}
+
+func TestIsInTerminalPhase(t *testing.T) {
+	for phase, expected := range map[godo.DeploymentPhase]bool{
+		godo.DeploymentPhase_PendingBuild: false,
+		godo.DeploymentPhase_Building:     false,
+		godo.DeploymentPhase_Deploying:    false,
+		godo.DeploymentPhase_Active:       true,
+		godo.DeploymentPhase_Error:        true,
+		godo.DeploymentPhase_Canceled:     true,
+		godo.DeploymentPhase_Superseded:   true,
+	} {
+		require.Equal(t, expected, IsInTerminalPhase(&godo.Deployment{Phase: phase}), phase)
+	}
+}
+
+func TestCancelDeployment(t *testing.T) {
+	var request string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request = r.Method + " " + r.URL.Path
+		w.Write([]byte(`{"deployment":{"id":"deployment-id","phase":"CANCELED"}}`))
+	}))
+	defer srv.Close()
+
+	client, err := godo.New(srv.Client(), godo.SetBaseURL(srv.URL))
+	require.NoError(t, err)
+
+	dep, _, err := NewDeploymentService(client).CancelDeployment(context.Background(), "app-id", "deployment-id")
+	require.NoError(t, err)
+	require.Equal(t, godo.DeploymentPhase_Canceled, dep.Phase)
+	require.Equal(t, "POST /v2/apps/app-id/deployments/deployment-id/cancel", request)
+}
@@ -2,7 +2,11 @@ package utils
 
 import (
 	"fmt"
+	"regexp"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	gha "github.com/sethvargo/go-githubactions"
 )
@@ -36,3 +40,123 @@ func InputAsBool(a *gha.Action, input string, required bool, target *bool) error
 	*target = val
 	return nil
 }
+
+// InputAsDuration parses the input as a duration and sets the target.
+func InputAsDuration(a *gha.Action, input string, required bool, target *time.Duration) error {
+	str := a.GetInput(input)
+	if str == "" {
+		if required {
+			return fmt.Errorf("input %q is required", input)
+		}
+
+		// If the input is not required, we default to zero.
+		*target = 0
+		return nil
+	}
+	val, err := time.ParseDuration(str)
+	if err != nil {
+		return fmt.Errorf("failed to parse %q as a duration: %v", input, err)
+	}
+	*target = val
+	return nil
+}
+
+// InputAsOneOf parses the input as a string that must be one of the given values and sets the
+// target.
+func InputAsOneOf(a *gha.Action, input string, required bool, values []string, target *string) error {
+	str := a.GetInput(input)
+	if str == "" {
+		if required {
+			return fmt.Errorf("input %q is required", input)
+		}
+		*target = ""
+		return nil
+	}
+	if !slices.Contains(values, str) {
+		return fmt.Errorf("input %q must be one of %q, got %q", input, values, str)
+	}
+	*target = str
+	return nil
+}
+
+// InputAsInt parses the input as an integer and sets the target.
+func InputAsInt(a *gha.Action, input string, required bool, target *int) error {
+	str := a.GetInput(input)
+	if str == "" {
+		if required {
+			return fmt.Errorf("input %q is required", input)
+		}
+
+		// If the input is not required, we default to zero.
+		*target = 0
+		return nil
+	}
+	val, err := strconv.Atoi(str)
+	if err != nil {
+		return fmt.Errorf("failed to parse %q as an integer: %v", input, err)
+	}
+	*target = val
+	return nil
+}
+
+// InputAsList parses the input as a list separated by newlines or commas and sets the target.
+// Empty items are ignored.
+func InputAsList(a *gha.Action, input string, required bool, target *[]string) error {
+	str := a.GetInput(input)
+	if str == "" && required {
+		return fmt.Errorf("input %q is required", input)
+	}
+
+	var res []string
+	for _, item := range strings.FieldsFunc(str, func(r rune) bool { return r == '\n' || r == ',' }) {
+		if item = strings.TrimSpace(item); item != "" {
+			res = append(res, item)
+		}
+	}
+	*target = res
+	return nil
+}
+
+// InputAsInts parses the input as a list of integers separated by newlines or commas and sets the
+// target.
+func InputAsInts(a *gha.Action, input string, required bool, target *[]int) error {
+	var items []string
+	if err := InputAsList(a, input, required, &items); err != nil {
+		return err
+	}
+
+	var res []int
+	for _, item := range items {
+		val, err := strconv.Atoi(item)
+		if err != nil {
+			return fmt.Errorf("failed to parse %q as a list of integers: %v", input, err)
+		}
+		res = append(res, val)
+	}
+	*target = res
+	return nil
+}
+
+// InputAsRegexps parses the input as a newline separated list of regular expressions and sets the
+// target. Empty lines are ignored.
+func InputAsRegexps(a *gha.Action, input string, required bool, target *[]*regexp.Regexp) error {
+	str := a.GetInput(input)
+	if str == "" && required {
+		return fmt.Errorf("input %q is required", input)
+	}
+
+	var res []*regexp.Regexp
+	for _, line := range strings.Split(str, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		re, err := regexp.Compile(line)
+		if err != nil {
+			return fmt.Errorf("failed to parse %q as a regular expression: %v", input, err)
+		}
+		res = append(res, re)
+	}
+	*target = res
+	return nil
+}
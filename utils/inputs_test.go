@@ -1,7 +1,9 @@
 package utils
 
 import (
+	"regexp"
 	"testing"
+	"time"
 
 	gha "github.com/sethvargo/go-githubactions"
 	"github.com/stretchr/testify/require"
@@ -111,3 +113,332 @@ func TestInputAsBool(t *testing.T) {
 		})
 	}
 }
+
+func TestInputAsDuration(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		required bool
+		expected time.Duration
+		err      bool
+	}{{
+		name:     "success",
+		input:    "input",
+		required: true,
+		expected: 90 * time.Second,
+	}, {
+		name:     "required",
+		input:    "empty",
+		required: true,
+		err:      true,
+	}, {
+		name:     "optional",
+		input:    "empty",
+		required: false,
+		expected: 0,
+	}, {
+		name:     "invalid",
+		input:    "invalid",
+		required: true,
+		err:      true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a := gha.New(gha.WithGetenv(func(k string) string {
+				switch k {
+				case "INPUT_INPUT":
+					return "1m30s"
+				case "INPUT_EMPTY":
+					return ""
+				case "INPUT_INVALID":
+					return "invalid"
+				default:
+					return "unexpected"
+				}
+			}))
+			target := new(time.Duration)
+			err := InputAsDuration(a, test.input, test.required, target)
+			if err != nil && !test.err {
+				require.NoError(t, err)
+			}
+			if err == nil && test.err {
+				require.Error(t, err)
+			}
+			require.Equal(t, test.expected, *target)
+		})
+	}
+}
+
+func TestInputAsOneOf(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		required bool
+		expected string
+		err      bool
+	}{{
+		name:     "success",
+		input:    "input",
+		required: true,
+		expected: "foo",
+	}, {
+		name:     "required",
+		input:    "empty",
+		required: true,
+		err:      true,
+	}, {
+		name:     "optional",
+		input:    "empty",
+		required: false,
+		expected: "",
+	}, {
+		name:     "invalid",
+		input:    "invalid",
+		required: true,
+		err:      true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a := gha.New(gha.WithGetenv(func(k string) string {
+				switch k {
+				case "INPUT_INPUT":
+					return "foo"
+				case "INPUT_EMPTY":
+					return ""
+				case "INPUT_INVALID":
+					return "baz"
+				default:
+					return "unexpected"
+				}
+			}))
+			target := new(string)
+			err := InputAsOneOf(a, test.input, test.required, []string{"foo", "bar"}, target)
+			if err != nil && !test.err {
+				require.NoError(t, err)
+			}
+			if err == nil && test.err {
+				require.Error(t, err)
+			}
+			require.Equal(t, test.expected, *target)
+		})
+	}
+}
+
+func TestInputAsRegexps(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		required bool
+		expected []*regexp.Regexp
+		err      bool
+	}{{
+		name:     "success",
+		input:    "input",
+		required: true,
+		expected: []*regexp.Regexp{regexp.MustCompile("panic:"), regexp.MustCompile("^FATAL")},
+	}, {
+		name:     "required",
+		input:    "empty",
+		required: true,
+		err:      true,
+	}, {
+		name:     "optional",
+		input:    "empty",
+		required: false,
+	}, {
+		name:     "invalid",
+		input:    "invalid",
+		required: true,
+		err:      true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a := gha.New(gha.WithGetenv(func(k string) string {
+				switch k {
+				case "INPUT_INPUT":
+					return "panic:\n\n  ^FATAL\n"
+				case "INPUT_EMPTY":
+					return ""
+				case "INPUT_INVALID":
+					return "(unclosed"
+				default:
+					return "unexpected"
+				}
+			}))
+			var target []*regexp.Regexp
+			err := InputAsRegexps(a, test.input, test.required, &target)
+			if err != nil && !test.err {
+				require.NoError(t, err)
+			}
+			if err == nil && test.err {
+				require.Error(t, err)
+			}
+			require.Equal(t, test.expected, target)
+		})
+	}
+}
+
+func TestInputAsInt(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		required bool
+		expected int
+		err      bool
+	}{{
+		name:     "success",
+		input:    "input",
+		required: true,
+		expected: 3,
+	}, {
+		name:     "required",
+		input:    "empty",
+		required: true,
+		err:      true,
+	}, {
+		name:     "optional",
+		input:    "empty",
+		required: false,
+		expected: 0,
+	}, {
+		name:     "invalid",
+		input:    "invalid",
+		required: true,
+		err:      true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a := gha.New(gha.WithGetenv(func(k string) string {
+				switch k {
+				case "INPUT_INPUT":
+					return "3"
+				case "INPUT_EMPTY":
+					return ""
+				case "INPUT_INVALID":
+					return "three"
+				default:
+					return "unexpected"
+				}
+			}))
+			target := new(int)
+			err := InputAsInt(a, test.input, test.required, target)
+			if err != nil && !test.err {
+				require.NoError(t, err)
+			}
+			if err == nil && test.err {
+				require.Error(t, err)
+			}
+			require.Equal(t, test.expected, *target)
+		})
+	}
+}
+
+func TestInputAsList(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		required bool
+		expected []string
+		err      bool
+	}{{
+		name:     "newlines",
+		input:    "newlines",
+		required: true,
+		expected: []string{"/health", "/ready"},
+	}, {
+		name:     "commas",
+		input:    "commas",
+		required: true,
+		expected: []string{"/health", "/ready"},
+	}, {
+		name:     "required",
+		input:    "empty",
+		required: true,
+		err:      true,
+	}, {
+		name:     "optional",
+		input:    "empty",
+		required: false,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a := gha.New(gha.WithGetenv(func(k string) string {
+				switch k {
+				case "INPUT_NEWLINES":
+					return "/health\n\n  /ready\n"
+				case "INPUT_COMMAS":
+					return "/health, /ready"
+				case "INPUT_EMPTY":
+					return ""
+				default:
+					return "unexpected"
+				}
+			}))
+			var target []string
+			err := InputAsList(a, test.input, test.required, &target)
+			if err != nil && !test.err {
+				require.NoError(t, err)
+			}
+			if err == nil && test.err {
+				require.Error(t, err)
+			}
+			require.Equal(t, test.expected, target)
+		})
+	}
+}
+
+func TestInputAsInts(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		required bool
+		expected []int
+		err      bool
+	}{{
+		name:     "success",
+		input:    "input",
+		required: true,
+		expected: []int{200, 204},
+	}, {
+		name:     "required",
+		input:    "empty",
+		required: true,
+		err:      true,
+	}, {
+		name:     "invalid",
+		input:    "invalid",
+		required: true,
+		err:      true,
+	}}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a := gha.New(gha.WithGetenv(func(k string) string {
+				switch k {
+				case "INPUT_INPUT":
+					return "200,204"
+				case "INPUT_EMPTY":
+					return ""
+				case "INPUT_INVALID":
+					return "200,ok"
+				default:
+					return "unexpected"
+				}
+			}))
+			var target []int
+			err := InputAsInts(a, test.input, test.required, &target)
+			if err != nil && !test.err {
+				require.NoError(t, err)
+			}
+			if err == nil && test.err {
+				require.Error(t, err)
+			}
+			require.Equal(t, test.expected, target)
+		})
+	}
+}
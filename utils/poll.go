@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// Backoff describes an exponential backoff with jitter.
+type Backoff struct {
+	// Initial is the delay after the first attempt.
+	Initial time.Duration
+	// Max caps the delay between attempts.
+	Max time.Duration
+	// Factor is the multiplier applied to the delay after each attempt.
+	Factor float64
+	// Jitter randomizes each delay by up to the given fraction in either direction.
+	Jitter float64
+}
+
+// DefaultBackoff is the backoff used to poll App Platform.
+var DefaultBackoff = Backoff{
+	Initial: 2 * time.Second,
+	Max:     15 * time.Second,
+	Factor:  1.5,
+	Jitter:  0.2,
+}
+
+// Poll calls fn until it reports that it's done or returns an error, waiting between attempts
+// according to the given backoff.
+func Poll(ctx context.Context, b Backoff, fn func() (bool, error)) error {
+	delay := b.Initial
+	for {
+		done, err := fn()
+		if err != nil || done {
+			return err
+		}
+
+		t := time.NewTimer(b.jitter(delay))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+
+		delay = min(time.Duration(float64(delay)*b.Factor), b.Max)
+	}
+}
+
+// jitter randomizes the given delay according to the backoff's jitter.
+func (b Backoff) jitter(d time.Duration) time.Duration {
+	if b.Jitter <= 0 {
+		return d
+	}
+	return d + time.Duration((rand.Float64()*2-1)*b.Jitter*float64(d))
+}
@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPoll(t *testing.T) {
+	ctx := context.Background()
+	b := Backoff{Initial: time.Millisecond, Max: 2 * time.Millisecond, Factor: 2, Jitter: 0.5}
+
+	var attempts int
+	err := Poll(ctx, b, func() (bool, error) {
+		attempts++
+		return attempts == 3, nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 3, attempts)
+
+	err = Poll(ctx, b, func() (bool, error) {
+		return false, errors.New("an error")
+	})
+	require.EqualError(t, err, "an error")
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	err = Poll(ctx, b, func() (bool, error) {
+		return false, nil
+	})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestBackoffJitter(t *testing.T) {
+	b := Backoff{Jitter: 0.2}
+	for range 100 {
+		d := b.jitter(time.Second)
+		require.GreaterOrEqual(t, d, 800*time.Millisecond)
+		require.LessOrEqual(t, d, 1200*time.Millisecond)
+	}
+
+	require.Equal(t, time.Second, Backoff{}.jitter(time.Second))
+}
@@ -0,0 +1,130 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/digitalocean/godo"
+	gha "github.com/sethvargo/go-githubactions"
+)
+
+// RollbackService provides access to App Platform's rollback APIs, which are not covered by godo.
+type RollbackService interface {
+	ValidateRollback(ctx context.Context, appID string, req *RollbackRequest) (*RollbackValidation, *godo.Response, error)
+	Rollback(ctx context.Context, appID string, req *RollbackRequest) (*godo.Deployment, *godo.Response, error)
+	CommitRollback(ctx context.Context, appID string) (*godo.Response, error)
+	RevertRollback(ctx context.Context, appID string) (*godo.Deployment, *godo.Response, error)
+}
+
+// RollbackRequest is a request to roll an app back to a previous deployment.
+type RollbackRequest struct {
+	// DeploymentID is the ID of the deployment to roll back to.
+	DeploymentID string `json:"deployment_id"`
+	// SkipPin skips pinning the app to the rolled back deployment. If the app is pinned, no
+	// new deployments can be created until the rollback is committed or reverted.
+	SkipPin bool `json:"skip_pin,omitempty"`
+}
+
+// RollbackValidation is the result of validating a rollback.
+type RollbackValidation struct {
+	Valid    bool                           `json:"valid"`
+	Error    *RollbackValidationCondition   `json:"error,omitempty"`
+	Warnings []*RollbackValidationCondition `json:"warnings,omitempty"`
+}
+
+// RollbackValidationCondition describes why a rollback is invalid or risky.
+type RollbackValidationCondition struct {
+	Code       string   `json:"code"`
+	Message    string   `json:"message"`
+	Components []string `json:"components,omitempty"`
+}
+
+// ExecuteRollback validates the given rollback, surfacing potential warnings, and executes it if
+// it's valid. It returns the deployment created by the rollback.
+func ExecuteRollback(ctx context.Context, a *gha.Action, rs RollbackService, appID string, req *RollbackRequest) (*godo.Deployment, error) {
+	validation, _, err := rs.ValidateRollback(ctx, appID, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate rollback: %w", err)
+	}
+	for _, w := range validation.Warnings {
+		a.Warningf("rollback warning: %s", w.Message)
+	}
+	if !validation.Valid {
+		if validation.Error != nil {
+			return nil, fmt.Errorf("rollback is invalid: %s", validation.Error.Message)
+		}
+		return nil, errors.New("rollback is invalid")
+	}
+
+	a.Infof("rolling back to deployment %s", req.DeploymentID)
+	dep, _, err := rs.Rollback(ctx, appID, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to roll back: %w", err)
+	}
+	return dep, nil
+}
+
+// NewRollbackService returns a RollbackService using the given client.
+func NewRollbackService(client *godo.Client) RollbackService {
+	return &rollbackService{client: client}
+}
+
+// rollbackService implements RollbackService on top of godo's client.
+type rollbackService struct {
+	client *godo.Client
+}
+
+// ValidateRollback validates whether the app can be rolled back to the given deployment.
+func (s *rollbackService) ValidateRollback(ctx context.Context, appID string, req *RollbackRequest) (*RollbackValidation, *godo.Response, error) {
+	path := fmt.Sprintf("/v2/apps/%s/rollback/validate", appID)
+	r, err := s.client.NewRequest(ctx, http.MethodPost, path, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	res := new(RollbackValidation)
+	resp, err := s.client.Do(ctx, r, res)
+	if err != nil {
+		return nil, resp, err
+	}
+	return res, resp, nil
+}
+
+// Rollback rolls the app back to the given deployment and returns the rollback deployment.
+func (s *rollbackService) Rollback(ctx context.Context, appID string, req *RollbackRequest) (*godo.Deployment, *godo.Response, error) {
+	path := fmt.Sprintf("/v2/apps/%s/rollback", appID)
+	return s.doDeploymentRequest(ctx, path, req)
+}
+
+// CommitRollback commits the app's current rollback, unpinning the app and making the rollback
+// permanent.
+func (s *rollbackService) CommitRollback(ctx context.Context, appID string) (*godo.Response, error) {
+	path := fmt.Sprintf("/v2/apps/%s/rollback/commit", appID)
+	r, err := s.client.NewRequest(ctx, http.MethodPost, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return s.client.Do(ctx, r, nil)
+}
+
+// RevertRollback reverts the app's current rollback, redeploying the deployment that was active
+// before the rollback.
+func (s *rollbackService) RevertRollback(ctx context.Context, appID string) (*godo.Deployment, *godo.Response, error) {
+	path := fmt.Sprintf("/v2/apps/%s/rollback/revert", appID)
+	return s.doDeploymentRequest(ctx, path, nil)
+}
+
+// doDeploymentRequest issues a POST request to the given path and decodes the returned deployment.
+func (s *rollbackService) doDeploymentRequest(ctx context.Context, path string, body interface{}) (*godo.Deployment, *godo.Response, error) {
+	r, err := s.client.NewRequest(ctx, http.MethodPost, path, body)
+	if err != nil {
+		return nil, nil, err
+	}
+	root := new(deploymentRoot)
+	resp, err := s.client.Do(ctx, r, root)
+	if err != nil {
+		return nil, resp, err
+	}
+	return root.Deployment, resp, nil
+}
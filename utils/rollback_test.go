@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/digitalocean/godo"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRollbackService(t *testing.T) {
+	ctx := context.Background()
+
+	var requests []string
+	var bodies []map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		var body map[string]interface{}
+		if r.ContentLength > 0 {
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		}
+		bodies = append(bodies, body)
+
+		switch r.URL.Path {
+		case "/v2/apps/app-id/rollback/validate":
+			w.Write([]byte(`{"valid":false,"error":{"code":"incompatible_result","message":"not possible"},"warnings":[{"code":"static_site_requires_rebuild","message":"rebuild","components":["web"]}]}`))
+		case "/v2/apps/app-id/rollback", "/v2/apps/app-id/rollback/revert":
+			w.Write([]byte(`{"deployment":{"id":"rollback-id"}}`))
+		case "/v2/apps/app-id/rollback/commit":
+			w.WriteHeader(http.StatusOK)
+		case "/v2/apps/missing/rollback":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"id":"not_found","message":"app not found"}`))
+		}
+	}))
+	defer srv.Close()
+
+	client, err := godo.New(srv.Client(), godo.SetBaseURL(srv.URL))
+	require.NoError(t, err)
+	rs := NewRollbackService(client)
+	req := &RollbackRequest{DeploymentID: "deployment-id", SkipPin: true}
+
+	validation, _, err := rs.ValidateRollback(ctx, "app-id", req)
+	require.NoError(t, err)
+	require.Equal(t, &RollbackValidation{
+		Valid: false,
+		Error: &RollbackValidationCondition{Code: "incompatible_result", Message: "not possible"},
+		Warnings: []*RollbackValidationCondition{{
+			Code:       "static_site_requires_rebuild",
+			Message:    "rebuild",
+			Components: []string{"web"},
+		}},
+	}, validation)
+
+	dep, _, err := rs.Rollback(ctx, "app-id", req)
+	require.NoError(t, err)
+	require.Equal(t, "rollback-id", dep.ID)
+
+	_, err = rs.CommitRollback(ctx, "app-id")
+	require.NoError(t, err)
+
+	dep, _, err = rs.RevertRollback(ctx, "app-id")
+	require.NoError(t, err)
+	require.Equal(t, "rollback-id", dep.ID)
+
+	_, resp, err := rs.Rollback(ctx, "missing", req)
+	require.Error(t, err)
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	require.Equal(t, []string{
+		"POST /v2/apps/app-id/rollback/validate",
+		"POST /v2/apps/app-id/rollback",
+		"POST /v2/apps/app-id/rollback/commit",
+		"POST /v2/apps/app-id/rollback/revert",
+		"POST /v2/apps/missing/rollback",
+	}, requests)
+	require.Equal(t, map[string]interface{}{"deployment_id": "deployment-id", "skip_pin": true}, bodies[0])
+	require.Nil(t, bodies[2])
+}